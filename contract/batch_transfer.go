@@ -0,0 +1,121 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+// BatchTransferABI 批量转账辅助合约ABI
+// 合约在一笔交易内依次调用各NFT合约的safeTransferFrom，任一转账失败则整笔交易回滚
+// 卖家需对辅助合约执行setApprovalForAll授权
+const BatchTransferABI = `[
+	{
+		"inputs": [
+			{"internalType": "address[]", "name": "tokens", "type": "address[]"},
+			{"internalType": "uint256[]", "name": "tokenIds", "type": "uint256[]"},
+			{"internalType": "address", "name": "from", "type": "address"},
+			{"internalType": "address", "name": "to", "type": "address"}
+		],
+		"name": "batchTransferFrom",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	}
+]`
+
+// BatchTransferTransactor 批量转账交易器
+type BatchTransferTransactor struct {
+	client       *ethclient.Client
+	abi          abi.ABI
+	contractAddr common.Address
+	chainID      *big.Int
+}
+
+// NewBatchTransferTransactor 创建批量转账交易器
+func NewBatchTransferTransactor(rpcUrl string, contractAddr string) (*BatchTransferTransactor, error) {
+	// 连接区块链节点
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		utils.Logger.Error("连接区块链节点失败", zap.String("rpcUrl", rpcUrl), zap.Error(err))
+		return nil, err
+	}
+
+	// 解析ABI
+	abiObj, err := abi.JSON(strings.NewReader(BatchTransferABI))
+	if err != nil {
+		utils.Logger.Error("解析ABI失败", zap.Error(err))
+		client.Close()
+		return nil, err
+	}
+
+	// 获取链ID
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		utils.Logger.Error("获取链ID失败", zap.Error(err))
+		client.Close()
+		return nil, err
+	}
+
+	return &BatchTransferTransactor{
+		client:       client,
+		abi:          abiObj,
+		contractAddr: common.HexToAddress(contractAddr),
+		chainID:      chainID,
+	}, nil
+}
+
+// Close 关闭区块链节点连接
+func (b *BatchTransferTransactor) Close() {
+	b.client.Close()
+}
+
+// SignBatchTransferFrom 构建并签名批量转账交易（不广播），一笔交易内转移多个NFT（全部成功或全部失败）
+// params:
+// - ctx: 上下文（估算Gas、查询nonce）
+// - signer: 发起转账的市场操作员签名器（卖家须已对辅助合约执行setApprovalForAll授权）
+// - tokens: 各NFT的合约地址
+// - tokenIds: 各NFT的代币ID（与tokens一一对应）
+// - from: 卖家地址
+// - to: 买家地址
+// return: 已签名交易、错误
+func (b *BatchTransferTransactor) SignBatchTransferFrom(ctx context.Context, signer Signer, tokens, tokenIds []string, from, to string) (*types.Transaction, error) {
+	if len(tokens) == 0 || len(tokens) != len(tokenIds) {
+		return nil, errors.New("tokens and tokenIds length mismatch")
+	}
+
+	// 构建交易授权
+	auth := newTransactOpts(ctx, signer, b.chainID)
+
+	// 转换参数
+	tokenAddrs := make([]common.Address, len(tokens))
+	ids := make([]*big.Int, len(tokenIds))
+	for i := range tokens {
+		tokenAddrs[i] = common.HexToAddress(tokens[i])
+		id, ok := new(big.Int).SetString(tokenIds[i], 10)
+		if !ok {
+			utils.Logger.Error("转换TokenID失败", zap.String("tokenId", tokenIds[i]))
+			return nil, errors.New("invalid tokenId: " + tokenIds[i])
+		}
+		ids[i] = id
+	}
+
+	// 构建并签名交易（估算Gas失败即交易将回滚）
+	contract := bind.NewBoundContract(b.contractAddr, b.abi, b.client, b.client, b.client)
+	tx, err := contract.Transact(auth, "batchTransferFrom", tokenAddrs, ids, common.HexToAddress(from), common.HexToAddress(to))
+	if err != nil {
+		utils.Logger.Error("签名batchTransferFrom交易失败", zap.Error(err))
+		return nil, err
+	}
+	return tx, nil
+}
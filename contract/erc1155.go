@@ -0,0 +1,159 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+// ERC1155ABI ERC1155合约基础ABI（safeTransferFrom及持有量/授权查询方法）
+const ERC1155ABI = `[
+	{
+		"inputs": [
+			{"internalType": "address", "name": "from", "type": "address"},
+			{"internalType": "address", "name": "to", "type": "address"},
+			{"internalType": "uint256", "name": "id", "type": "uint256"},
+			{"internalType": "uint256", "name": "amount", "type": "uint256"},
+			{"internalType": "bytes", "name": "data", "type": "bytes"}
+		],
+		"name": "safeTransferFrom",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "account", "type": "address"},
+			{"internalType": "uint256", "name": "id", "type": "uint256"}
+		],
+		"name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "account", "type": "address"},
+			{"internalType": "address", "name": "operator", "type": "address"}
+		],
+		"name": "isApprovedForAll",
+		"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// ERC1155Transactor ERC1155交易器
+type ERC1155Transactor struct {
+	client       *ethclient.Client
+	abi          abi.ABI
+	contractAddr common.Address
+	chainID      *big.Int
+}
+
+// NewERC1155Transactor 创建ERC1155交易器
+func NewERC1155Transactor(rpcUrl string, contractAddr string) (*ERC1155Transactor, error) {
+	// 连接区块链节点
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		utils.Logger.Error("连接区块链节点失败", zap.String("rpcUrl", rpcUrl), zap.Error(err))
+		return nil, err
+	}
+
+	// 解析ABI
+	abiObj, err := abi.JSON(strings.NewReader(ERC1155ABI))
+	if err != nil {
+		utils.Logger.Error("解析ABI失败", zap.Error(err))
+		client.Close()
+		return nil, err
+	}
+
+	// 获取链ID
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		utils.Logger.Error("获取链ID失败", zap.Error(err))
+		client.Close()
+		return nil, err
+	}
+
+	return &ERC1155Transactor{
+		client:       client,
+		abi:          abiObj,
+		contractAddr: common.HexToAddress(contractAddr),
+		chainID:      chainID,
+	}, nil
+}
+
+// Close 关闭区块链节点连接
+func (e *ERC1155Transactor) Close() {
+	e.client.Close()
+}
+
+// SignSafeTransferFrom 构建并签名ERC1155安全转账交易（不广播）
+// params:
+// - ctx: 上下文（估算Gas、查询nonce）
+// - signer: 发起转账的市场操作员签名器（卖家须已对操作员执行setApprovalForAll授权）
+// - from: 卖家地址
+// - to: 买家地址
+// - id: 代币ID
+// - amount: 转账数量
+// - data: 附加数据（传给接收合约的onERC1155Received，可为空）
+// return: 已签名交易、错误
+func (e *ERC1155Transactor) SignSafeTransferFrom(ctx context.Context, signer Signer, from, to, id string, amount int64, data []byte) (*types.Transaction, error) {
+	// 构建交易授权
+	auth := newTransactOpts(ctx, signer, e.chainID)
+
+	tokenID, ok := new(big.Int).SetString(id, 10)
+	if !ok {
+		utils.Logger.Error("转换TokenID失败", zap.String("id", id))
+		return nil, errors.New("TokenID格式错误")
+	}
+	if data == nil {
+		data = []byte{}
+	}
+
+	// 构建并签名交易（估算Gas失败即交易将回滚）
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	tx, err := contract.Transact(auth, "safeTransferFrom", common.HexToAddress(from), common.HexToAddress(to), tokenID, big.NewInt(amount), data)
+	if err != nil {
+		utils.Logger.Error("签名safeTransferFrom交易失败", zap.Error(err))
+		return nil, err
+	}
+	return tx, nil
+}
+
+// BalanceOf 查询账户持有的代币数量
+func (e *ERC1155Transactor) BalanceOf(ctx context.Context, account, id string) (*big.Int, error) {
+	tokenID, ok := new(big.Int).SetString(id, 10)
+	if !ok {
+		return nil, errors.New("TokenID格式错误")
+	}
+	var out []interface{}
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "balanceOf", common.HexToAddress(account), tokenID); err != nil {
+		utils.Logger.Error("调用balanceOf失败", zap.String("contract", e.contractAddr.Hex()), zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// IsApprovedForAll 查询account是否已对operator执行setApprovalForAll授权
+func (e *ERC1155Transactor) IsApprovedForAll(ctx context.Context, account, operator string) (bool, error) {
+	var out []interface{}
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "isApprovedForAll", common.HexToAddress(account), common.HexToAddress(operator)); err != nil {
+		utils.Logger.Error("调用isApprovedForAll失败", zap.String("contract", e.contractAddr.Hex()), zap.Error(err))
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
@@ -0,0 +1,101 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// ERC1271ABI ERC-1271合约钱包签名校验ABI（仅包含isValidSignature方法）
+const ERC1271ABI = `[
+	{
+		"inputs": [
+			{"internalType": "bytes32", "name": "hash", "type": "bytes32"},
+			{"internalType": "bytes", "name": "signature", "type": "bytes"}
+		],
+		"name": "isValidSignature",
+		"outputs": [{"internalType": "bytes4", "name": "magicValue", "type": "bytes4"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// ERC1271MagicValue 签名有效时isValidSignature的返回值（bytes4(keccak256("isValidSignature(bytes32,bytes)"))）
+var ERC1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+// ERC1271Caller 合约钱包签名校验器（只读调用）
+type ERC1271Caller struct {
+	backend bind.ContractCaller
+	abi     abi.ABI
+}
+
+// NewERC1271Caller 基于任意只读后端创建签名校验器（RPC客户端或go-ethereum模拟链）
+func NewERC1271Caller(backend bind.ContractCaller) (*ERC1271Caller, error) {
+	abiObj, err := abi.JSON(strings.NewReader(ERC1271ABI))
+	if err != nil {
+		utils.Logger.Error("解析ABI失败", zap.Error(err))
+		return nil, err
+	}
+	return &ERC1271Caller{
+		backend: backend,
+		abi:     abiObj,
+	}, nil
+}
+
+// DialERC1271Caller 连接区块链节点并创建签名校验器
+func DialERC1271Caller(rpcUrl string) (*ERC1271Caller, error) {
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		utils.Logger.Error("连接区块链节点失败", zap.String("rpcUrl", rpcUrl), zap.Error(err))
+		return nil, err
+	}
+	return NewERC1271Caller(client)
+}
+
+// HasCode 查询地址是否部署了合约代码（合约钱包）
+func (e *ERC1271Caller) HasCode(ctx context.Context, addr common.Address) (bool, error) {
+	code, err := e.backend.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// IsValidSignature 调用合约钱包的isValidSignature校验签名
+// params:
+// - wallet: 合约钱包地址
+// - hash: 待签哈希（personal_sign为EIP-191哈希，结构化签名为EIP-712哈希）
+// - signature: 签名原始字节（长度由合约钱包自行约定，如多签钱包为多个签名拼接）
+// return: 返回值为ERC1271MagicValue时为true；合约回滚或无代码视为签名无效，节点限流、连接等其他错误返回err（结果不可缓存）
+func (e *ERC1271Caller) IsValidSignature(ctx context.Context, wallet common.Address, hash [32]byte, signature []byte) (bool, error) {
+	contract := bind.NewBoundContract(wallet, e.abi, e.backend, nil, nil)
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "isValidSignature", hash, signature); err != nil {
+		if errors.Is(err, bind.ErrNoCode) || isExecutionReverted(err) {
+			utils.Logger.Warn("合约钱包拒绝签名", zap.String("wallet", wallet.Hex()), zap.Error(err))
+			return false, nil
+		}
+		utils.Logger.Error("调用isValidSignature失败", zap.String("wallet", wallet.Hex()), zap.Error(err))
+		return false, err
+	}
+	magicValue := *abi.ConvertType(out[0], new([4]byte)).(*[4]byte)
+	return magicValue == ERC1271MagicValue, nil
+}
+
+// isExecutionReverted 判断调用是否被合约回滚（节点以错误码3返回revert，部分节点仅返回"execution reverted"消息）
+func isExecutionReverted(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
@@ -2,6 +2,7 @@ package contract
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"strings"
 
@@ -10,12 +11,12 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"go.uber.org/zap"
 )
 
-// ERC721ABI ERC721合约基础ABI（仅包含safeTransferFrom方法）
+// ERC721ABI ERC721合约基础ABI（safeTransferFrom及所有权/授权查询方法）
 const ERC721ABI = `[
 	{
 		"inputs": [
@@ -27,6 +28,30 @@ const ERC721ABI = `[
 		"outputs": [],
 		"stateMutability": "nonpayable",
 		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "uint256", "name": "tokenId", "type": "uint256"}],
+		"name": "ownerOf",
+		"outputs": [{"internalType": "address", "name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "uint256", "name": "tokenId", "type": "uint256"}],
+		"name": "getApproved",
+		"outputs": [{"internalType": "address", "name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"internalType": "address", "name": "owner", "type": "address"},
+			{"internalType": "address", "name": "operator", "type": "address"}
+		],
+		"name": "isApprovedForAll",
+		"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+		"stateMutability": "view",
+		"type": "function"
 	}
 ]`
 
@@ -51,6 +76,7 @@ func NewERC721Transactor(rpcUrl string, contractAddr string) (*ERC721Transactor,
 	abiObj, err := abi.JSON(strings.NewReader(ERC721ABI))
 	if err != nil {
 		utils.Logger.Error("解析ABI失败", zap.Error(err))
+		client.Close()
 		return nil, err
 	}
 
@@ -58,6 +84,7 @@ func NewERC721Transactor(rpcUrl string, contractAddr string) (*ERC721Transactor,
 	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		utils.Logger.Error("获取链ID失败", zap.Error(err))
+		client.Close()
 		return nil, err
 	}
 
@@ -69,58 +96,78 @@ func NewERC721Transactor(rpcUrl string, contractAddr string) (*ERC721Transactor,
 	}, nil
 }
 
-// SafeTransferFrom 执行ERC721安全转账
+// Close 关闭区块链节点连接
+func (e *ERC721Transactor) Close() {
+	e.client.Close()
+}
+
+// SignSafeTransferFrom 构建并签名ERC721安全转账交易（不广播）
 // params:
-// - privateKey: 卖家私钥（生产环境需使用钱包签名，勿直接存储）
+// - ctx: 上下文（估算Gas、查询nonce）
+// - signer: 发起转账的市场操作员签名器（卖家须已对操作员执行setApprovalForAll授权）
 // - from: 卖家地址
 // - to: 买家地址
 // - tokenId: 代币ID
-// return: 交易哈希、错误
-func (e *ERC721Transactor) SafeTransferFrom(privateKey string, from, to, tokenId string) (string, error) {
-	ctx := context.Background()
-
-	// 解析私钥
-	// 生产环境：使用钱包签名（如MetaMask），避免直接处理私钥
-	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
-	if err != nil {
-		utils.Logger.Error("解析私钥失败", zap.Error(err))
-		return "", err
-	}
-
+// return: 已签名交易、错误
+func (e *ERC721Transactor) SignSafeTransferFrom(ctx context.Context, signer Signer, from, to, tokenId string) (*types.Transaction, error) {
 	// 构建交易授权
-	auth, err := bind.NewKeyedTransactorWithChainID(key, e.chainID)
-	if err != nil {
-		utils.Logger.Error("构建交易授权失败", zap.Error(err))
-		return "", err
-	}
+	auth := newTransactOpts(ctx, signer, e.chainID)
 
 	// 转换TokenID为big.Int
 	tokenID := new(big.Int)
 	_, ok := tokenID.SetString(tokenId, 10)
 	if !ok {
 		utils.Logger.Error("转换TokenID失败", zap.String("tokenId", tokenId))
-		return "", err
+		return nil, errors.New("TokenID格式错误")
 	}
 
-	// 调用合约方法
+	// 构建并签名交易（估算Gas失败即交易将回滚）
 	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
 	tx, err := contract.Transact(auth, "safeTransferFrom", common.HexToAddress(from), common.HexToAddress(to), tokenID)
 	if err != nil {
-		utils.Logger.Error("执行safeTransferFrom失败", zap.Error(err))
-		return "", err
+		utils.Logger.Error("签名safeTransferFrom交易失败", zap.Error(err))
+		return nil, err
 	}
+	return tx, nil
+}
 
-	// 等待交易上链（可选，也可异步监听）
-	receipt, err := bind.WaitMined(ctx, e.client, tx)
-	if err != nil {
-		utils.Logger.Error("等待交易上链失败", zap.String("txHash", tx.Hash().Hex()), zap.Error(err))
-		return "", err
+// OwnerOf 查询NFT当前持有者
+func (e *ERC721Transactor) OwnerOf(ctx context.Context, tokenId string) (common.Address, error) {
+	tokenID, ok := new(big.Int).SetString(tokenId, 10)
+	if !ok {
+		return common.Address{}, errors.New("TokenID格式错误")
+	}
+	var out []interface{}
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "ownerOf", tokenID); err != nil {
+		utils.Logger.Error("调用ownerOf失败", zap.String("contract", e.contractAddr.Hex()), zap.String("tokenId", tokenId), zap.Error(err))
+		return common.Address{}, err
 	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
 
-	if receipt.Status == 0 {
-		utils.Logger.Error("交易执行失败（状态为0）", zap.String("txHash", tx.Hash().Hex()))
-		return "", err
+// GetApproved 查询NFT的单独授权地址（未授权为零地址）
+func (e *ERC721Transactor) GetApproved(ctx context.Context, tokenId string) (common.Address, error) {
+	tokenID, ok := new(big.Int).SetString(tokenId, 10)
+	if !ok {
+		return common.Address{}, errors.New("TokenID格式错误")
+	}
+	var out []interface{}
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "getApproved", tokenID); err != nil {
+		utils.Logger.Error("调用getApproved失败", zap.String("contract", e.contractAddr.Hex()), zap.String("tokenId", tokenId), zap.Error(err))
+		return common.Address{}, err
 	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
 
-	return tx.Hash().Hex(), nil
+// IsApprovedForAll 查询owner是否已对operator执行setApprovalForAll授权
+func (e *ERC721Transactor) IsApprovedForAll(ctx context.Context, owner, operator string) (bool, error) {
+	var out []interface{}
+	contract := bind.NewBoundContract(e.contractAddr, e.abi, e.client, e.client, e.client)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "isApprovedForAll", common.HexToAddress(owner), common.HexToAddress(operator)); err != nil {
+		utils.Logger.Error("调用isApprovedForAll失败", zap.String("contract", e.contractAddr.Hex()), zap.Error(err))
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
 }
@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"context"
+	"math/big"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+// ERC721TransferTopic Transfer(address,address,uint256)事件签名
+// ERC20的Transfer事件签名相同，但tokenId不作为indexed参数，按topic数量区分
+var ERC721TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// ERC721Transfer 一次ERC721转移事件
+type ERC721Transfer struct {
+	ContractAddr common.Address
+	From         common.Address // 铸造时为零地址
+	To           common.Address // 销毁时为零地址
+	TokenID      *big.Int
+	BlockNumber  uint64
+	BlockHash    common.Hash
+	TxHash       common.Hash
+	LogIndex     uint
+}
+
+// LogBackend 日志查询后端（RPC客户端或go-ethereum模拟链）
+type LogBackend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// ERC721LogFilter ERC721转移事件查询器
+type ERC721LogFilter struct {
+	backend LogBackend
+}
+
+// NewERC721LogFilter 基于任意日志后端创建转移事件查询器
+func NewERC721LogFilter(backend LogBackend) *ERC721LogFilter {
+	return &ERC721LogFilter{backend: backend}
+}
+
+// DialERC721LogFilter 连接区块链节点并创建转移事件查询器
+func DialERC721LogFilter(rpcUrl string) (*ERC721LogFilter, error) {
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		utils.Logger.Error("连接区块链节点失败", zap.String("rpcUrl", rpcUrl), zap.Error(err))
+		return nil, err
+	}
+	return NewERC721LogFilter(client), nil
+}
+
+// BlockNumber 查询最新区块高度
+func (f *ERC721LogFilter) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.backend.BlockNumber(ctx)
+}
+
+// FilterTransfers 查询[fromBlock, toBlock]区间内指定合约的Transfer事件（按区块、日志顺序返回，已忽略被回滚的日志）
+func (f *ERC721LogFilter) FilterTransfers(ctx context.Context, contracts []common.Address, fromBlock, toBlock uint64) ([]ERC721Transfer, error) {
+	logs, err := f.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{ERC721TransferTopic}},
+	})
+	if err != nil {
+		utils.Logger.Error("查询Transfer事件失败", zap.Uint64("from_block", fromBlock), zap.Uint64("to_block", toBlock), zap.Error(err))
+		return nil, err
+	}
+
+	transfers := make([]ERC721Transfer, 0, len(logs))
+	for _, log := range logs {
+		if log.Removed || len(log.Topics) != 4 {
+			continue
+		}
+		transfers = append(transfers, ERC721Transfer{
+			ContractAddr: log.Address,
+			From:         common.BytesToAddress(log.Topics[1].Bytes()),
+			To:           common.BytesToAddress(log.Topics[2].Bytes()),
+			TokenID:      new(big.Int).SetBytes(log.Topics[3].Bytes()),
+			BlockNumber:  log.BlockNumber,
+			BlockHash:    log.BlockHash,
+			TxHash:       log.TxHash,
+			LogIndex:     log.Index,
+		})
+	}
+	return transfers, nil
+}
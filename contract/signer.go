@@ -0,0 +1,196 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// Signer 交易签名器：发送交易的代码只依赖该接口，密钥可轮换或迁移至HSM/远程签名服务而无需修改业务代码
+type Signer interface {
+	// Address 签名账户地址（交易发送方）
+	Address() common.Address
+	// SignTx 按链ID对交易签名
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// newTransactOpts 基于签名器构建交易授权（仅签名不广播，由调用方持久化原始交易后经TxTracker广播）
+func newTransactOpts(ctx context.Context, signer Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:    signer.Address(),
+		Context: ctx,
+		NoSend:  true,
+		Signer: func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+// -------------- 加密keystore签名器 --------------
+// KeystoreSigner go-ethereum加密keystore文件签名器（启动时解锁，私钥仅驻留内存）
+type KeystoreSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeystoreSigner 读取并解锁keystore文件
+func NewKeystoreSigner(path, password string) (*KeystoreSigner, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		utils.Logger.Error("读取keystore文件失败", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		utils.Logger.Error("解锁keystore失败", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+	return &KeystoreSigner{
+		key:     key.PrivateKey,
+		address: key.Address,
+	}, nil
+}
+
+// Address 签名账户地址
+func (k *KeystoreSigner) Address() common.Address {
+	return k.address
+}
+
+// SignTx 使用解锁的私钥签名交易
+func (k *KeystoreSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), k.key)
+}
+
+// -------------- 远程签名器 --------------
+// RemoteSignReq 远程签名请求（POST {url}/sign）
+type RemoteSignReq struct {
+	Address string `json:"address"`  // 签名账户地址
+	ChainID string `json:"chain_id"` // 链ID（十进制）
+	Tx      string `json:"tx"`       // 待签名交易（MarshalBinary十六进制编码）
+}
+
+// RemoteSignResp 远程签名响应
+type RemoteSignResp struct {
+	SignedTx string `json:"signed_tx"` // 已签名交易（MarshalBinary十六进制编码）
+	Error    string `json:"error"`     // 签名失败原因
+}
+
+// RemoteSigner HTTP远程签名服务客户端（密钥保存在签名服务/HSM中，不经过本服务）
+type RemoteSigner struct {
+	url     string
+	token   string
+	address common.Address
+	client  *http.Client
+}
+
+// NewRemoteSigner 创建远程签名器
+// params:
+// - url: 签名服务地址
+// - address: 签名账户地址
+// - token: 可选，签名服务鉴权令牌（以Authorization: Bearer发送）
+func NewRemoteSigner(url, address, token string) (*RemoteSigner, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("远程签名账户地址格式错误")
+	}
+	return &RemoteSigner{
+		url:     strings.TrimSuffix(url, "/"),
+		token:   token,
+		address: common.HexToAddress(address),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Address 签名账户地址
+func (r *RemoteSigner) Address() common.Address {
+	return r.address
+}
+
+// SignTx 请求远程签名服务签名交易，并校验返回交易的签名账户与内容未被篡改
+func (r *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	// 1. 编码待签名交易
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(RemoteSignReq{
+		Address: r.address.Hex(),
+		ChainID: chainID.String(),
+		Tx:      hexutil.Encode(rawTx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 请求签名服务
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+"/sign", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	httpResp, err := r.client.Do(httpReq)
+	if err != nil {
+		utils.Logger.Error("请求远程签名服务失败", zap.String("url", r.url), zap.Error(err))
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// 非200响应体可能不是JSON，仅尽力解析失败原因
+	var resp RemoteSignResp
+	decodeErr := json.NewDecoder(httpResp.Body).Decode(&resp)
+	if httpResp.StatusCode != http.StatusOK {
+		utils.Logger.Error("远程签名失败", zap.Int("status", httpResp.StatusCode), zap.String("error", resp.Error))
+		return nil, fmt.Errorf("远程签名失败：状态码%d %s", httpResp.StatusCode, resp.Error)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("解析远程签名响应失败：%w", decodeErr)
+	}
+	if resp.Error != "" {
+		utils.Logger.Error("远程签名失败", zap.String("error", resp.Error))
+		return nil, fmt.Errorf("远程签名失败：%s", resp.Error)
+	}
+
+	// 3. 解码并校验已签名交易
+	signedRaw, err := hexutil.Decode(resp.SignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("远程签名交易格式错误：%w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(signedRaw); err != nil {
+		return nil, fmt.Errorf("远程签名交易格式错误：%w", err)
+	}
+	txSigner := types.LatestSignerForChainID(chainID)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, errors.New("远程签名交易内容与待签名交易不一致")
+	}
+	sender, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("远程签名交易签名无效：%w", err)
+	}
+	if sender != r.address {
+		return nil, errors.New("远程签名账户与配置地址不一致")
+	}
+	return signed, nil
+}
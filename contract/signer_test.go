@@ -0,0 +1,168 @@
+package contract
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newRemoteSignServer 模拟远程签名服务：sign按请求签名交易，返回签名后的交易（或错误状态码）
+func newRemoteSignServer(t *testing.T, sign func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/sign" {
+			http.NotFound(w, r)
+			return
+		}
+		var req RemoteSignReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		raw, err := hexutil.Decode(req.Tx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		chainID, _ := new(big.Int).SetString(req.ChainID, 10)
+
+		signed, status := sign(tx, chainID)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("signer unavailable"))
+			return
+		}
+		signedRaw, err := signed.MarshalBinary()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(RemoteSignResp{SignedTx: hexutil.Encode(signedRaw)})
+	}))
+}
+
+// signWith 使用指定私钥签名交易
+func signWith(t *testing.T, key *ecdsa.PrivateKey, tx *types.Transaction, chainID *big.Int) *types.Transaction {
+	t.Helper()
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestRemoteSignerSignTx(t *testing.T) {
+	if err := utils.InitLogger(); err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	unsigned := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       100000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x01, 0x02},
+	})
+
+	tests := []struct {
+		name    string
+		sign    func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int)
+		wantErr string
+	}{
+		{
+			name: "正常签名",
+			sign: func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int) {
+				return signWith(t, key, tx, chainID), http.StatusOK
+			},
+		},
+		{
+			name: "交易内容被篡改",
+			sign: func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int) {
+				tampered := types.NewTx(&types.DynamicFeeTx{
+					ChainID:   chainID,
+					Nonce:     tx.Nonce(),
+					GasTipCap: tx.GasTipCap(),
+					GasFeeCap: tx.GasFeeCap(),
+					Gas:       tx.Gas(),
+					To:        tx.To(),
+					Value:     big.NewInt(1),
+					Data:      tx.Data(),
+				})
+				return signWith(t, key, tampered, chainID), http.StatusOK
+			},
+			wantErr: "内容与待签名交易不一致",
+		},
+		{
+			name: "签名账户不一致",
+			sign: func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int) {
+				return signWith(t, otherKey, tx, chainID), http.StatusOK
+			},
+			wantErr: "签名账户与配置地址不一致",
+		},
+		{
+			name: "签名服务返回非200状态码",
+			sign: func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, int) {
+				return nil, http.StatusServiceUnavailable
+			},
+			wantErr: "状态码503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRemoteSignServer(t, tt.sign)
+			defer server.Close()
+
+			signer, err := NewRemoteSigner(server.URL, crypto.PubkeyToAddress(key.PublicKey).Hex(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed, err := signer.SignTx(context.Background(), unsigned, chainID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含%q，实际：%v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("签名失败：%v", err)
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sender != signer.Address() || signed.Nonce() != unsigned.Nonce() {
+				t.Fatalf("签名交易不符：sender=%s nonce=%d", sender.Hex(), signed.Nonce())
+			}
+		})
+	}
+}
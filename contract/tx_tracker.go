@@ -0,0 +1,120 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+// TxBackend 交易广播与状态查询后端（RPC客户端或go-ethereum模拟链）
+type TxBackend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error)
+}
+
+// TxTracker 交易广播与确认状态查询器
+type TxTracker struct {
+	backend TxBackend
+}
+
+// NewTxTracker 基于任意查询后端创建交易确认状态查询器
+func NewTxTracker(backend TxBackend) *TxTracker {
+	return &TxTracker{backend: backend}
+}
+
+// DialTxTracker 连接区块链节点并创建交易确认状态查询器
+func DialTxTracker(rpcUrl string) (*TxTracker, error) {
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		utils.Logger.Error("连接区块链节点失败", zap.String("rpcUrl", rpcUrl), zap.Error(err))
+		return nil, err
+	}
+	return NewTxTracker(client), nil
+}
+
+// BlockNumber 查询最新区块高度
+func (t *TxTracker) BlockNumber(ctx context.Context) (uint64, error) {
+	return t.backend.BlockNumber(ctx)
+}
+
+// SendTransaction 广播已签名交易（节点已持有同一交易时视为成功，可用于原样重新广播）
+func (t *TxTracker) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := t.backend.SendTransaction(ctx, tx); err != nil {
+		if strings.Contains(err.Error(), "already known") {
+			return nil
+		}
+		utils.Logger.Error("广播交易失败", zap.String("txHash", tx.Hash().Hex()), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ConfirmedNonce 查询账户在已达到确认区块数的区块上的nonce（小于该值的nonce已被不可回滚的交易占用）
+func (t *TxTracker) ConfirmedNonce(ctx context.Context, account common.Address, depth uint64) (uint64, error) {
+	head, err := t.backend.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	depth = max(depth, 1)
+	if head+1 < depth {
+		return 0, nil
+	}
+	return t.backend.NonceAt(ctx, account, new(big.Int).SetUint64(head+1-depth))
+}
+
+// TransactionStatus 查询交易在当前规范链上的状态
+// return: 已打包时返回回执（区块哈希为当前规范链上的区块）；未打包但仍在交易池中时pending为true；
+// 两者皆无表示交易已被链重组移除或被同nonce交易替换
+func (t *TxTracker) TransactionStatus(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, pending bool, err error) {
+	receipt, err = t.backend.TransactionReceipt(ctx, txHash)
+	if err == nil {
+		return receipt, false, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return nil, false, err
+	}
+
+	_, pending, err = t.backend.TransactionByHash(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return nil, pending, nil
+}
+
+// EncodeRawTx 编码已签名交易（十六进制，持久化后可原样重新广播）
+func EncodeRawTx(tx *types.Transaction) (string, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// DecodeRawTx 解码EncodeRawTx编码的已签名交易
+func DecodeRawTx(rawTx string) (*types.Transaction, error) {
+	raw, err := hexutil.Decode(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
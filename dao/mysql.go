@@ -3,6 +3,7 @@ package dao
 import (
 	"fmt"
 	"nft_trade/model"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
@@ -63,3 +64,34 @@ func DeleteOrder(orderId string) error {
 
 	return nil
 }
+
+// GetNFTAssetById 根据ID查询NFT资产
+func GetNFTAssetById(nftId string) (*model.NFTAsset, error) {
+	var asset model.NFTAsset
+	if err := db.Where("id = ?", nftId).First(&asset).Error; err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// IsNFTAssetLocked 查询NFT资产是否被未解锁的挂单锁定
+func IsNFTAssetLocked(nftAssetID uint64) (bool, error) {
+	var count int64
+	if err := db.Model(&model.NFTAssetLock{}).Where("nft_asset_id = ? AND unlock_time IS NULL", nftAssetID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetNFTAssetLockedQty 查询持有者ERC-1155资产的挂单锁定数量（无持有量记录时为0）
+func GetNFTAssetLockedQty(nftAssetID uint64, ownerAddr string) (int64, error) {
+	var balance model.NFTAssetBalance
+	err := db.Where("nft_asset_id = ? AND owner_addr = ?", nftAssetID, strings.ToLower(ownerAddr)).First(&balance).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return balance.LockedQty, nil
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"nft_trade/service"
+	"nft_trade/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateAPIKey 为登录钱包创建API密钥（Secret仅返回一次）
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	var req service.CreateAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	req.UserAddr = c.GetString(authAddrKey)
+
+	resp, err := h.authService.CreateAPIKey(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// ListAPIKeys 查询登录钱包的API密钥列表
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	list, err := h.authService.ListAPIKeys(c.Request.Context(), c.GetString(authAddrKey))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": list},
+	})
+}
+
+// DeleteAPIKey 吊销API密钥
+func (h *AuthHandler) DeleteAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "id格式错误",
+		})
+		return
+	}
+
+	if err := h.authService.DeleteAPIKey(c.Request.Context(), c.GetString(authAddrKey), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"id": id},
+	})
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"nft_trade/service"
+	"nft_trade/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PlaceBid 英式拍卖出价
+func (h *TradeHandler) PlaceBid(c *gin.Context) {
+	var req service.PlaceBidReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.BidderAddr) {
+		return
+	}
+
+	bidNo, err := h.tradeService.PlaceBid(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"bid_no": bidNo},
+	})
+}
+
+// GetAuctionBids 查询拍卖出价列表
+func (h *TradeHandler) GetAuctionBids(c *gin.Context) {
+	orderNo := c.Query("order_no")
+	if orderNo == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "order_no不能为空",
+		})
+		return
+	}
+	h.getBids(c, service.GetBidsReq{OrderNo: orderNo})
+}
+
+// GetBidHistory 查询用户出价历史
+func (h *TradeHandler) GetBidHistory(c *gin.Context) {
+	bidderAddr := c.Query("bidder_addr")
+	if bidderAddr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "bidder_addr不能为空",
+		})
+		return
+	}
+	h.getBids(c, service.GetBidsReq{BidderAddr: bidderAddr})
+}
+
+// getBids 分页查询出价
+func (h *TradeHandler) getBids(c *gin.Context, req service.GetBidsReq) {
+	// 解析分页参数
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	req.Page = page
+	req.PageSize = pageSize
+
+	bids, total, err := h.tradeService.GetBids(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"list":      bids,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetDutchPrice 查询荷兰式拍卖当前价格
+func (h *TradeHandler) GetDutchPrice(c *gin.Context) {
+	orderNo := c.Query("order_no")
+	if orderNo == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "order_no不能为空",
+		})
+		return
+	}
+
+	price, err := h.tradeService.GetDutchPrice(c.Request.Context(), orderNo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": price,
+	})
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"nft_trade/service"
+	"nft_trade/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// authAddrKey 上下文中登录钱包地址的键
+const authAddrKey = "auth_addr"
+
+// API密钥鉴权请求头
+const (
+	apiKeyHeader       = "X-API-KEY"
+	apiTimestampHeader = "X-API-TIMESTAMP" // Unix毫秒
+	apiSignatureHeader = "X-API-SIGNATURE" // HMAC-SHA256十六进制，待签内容见service.APIKeySignPayload
+)
+
+// AuthHandler 登录处理器
+type AuthHandler struct {
+	authService service.AuthService
+}
+
+// NewAuthHandler 创建登录处理器
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// GetNonce 获取登录nonce
+func (h *AuthHandler) GetNonce(c *gin.Context) {
+	resp, err := h.authService.GetNonce(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// Verify 校验SIWE签名并签发会话
+func (h *AuthHandler) Verify(c *gin.Context) {
+	var req service.SIWEVerifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.authService.Verify(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidSIWEMessage) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrInvalidSignature) || errors.Is(err, service.ErrInvalidNonce) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// Logout 注销当前会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.authService.Logout(c.Request.Context(), bearerToken(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+	})
+}
+
+// Middleware 鉴权中间件：携带X-API-KEY时按API密钥HMAC签名鉴权（须具备scope权限），
+// 否则校验Authorization: Bearer会话令牌（会话拥有全部权限），并将登录钱包地址注入上下文
+func (h *AuthHandler) Middleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var addr string
+		var err error
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			addr, err = h.authenticateAPIKey(c, apiKey, scope)
+		} else {
+			addr, err = h.authService.Authenticate(c.Request.Context(), bearerToken(c))
+		}
+		if err != nil {
+			abortAuth(c, err)
+			return
+		}
+		c.Set(authAddrKey, addr)
+		c.Next()
+	}
+}
+
+// OptionalMiddleware 可选鉴权中间件（公开查询接口）：未携带凭证时匿名访问，
+// 携带凭证时按Middleware校验（凭证无效直接拒绝）并将登录钱包地址注入上下文
+func (h *AuthHandler) OptionalMiddleware(scope string) gin.HandlerFunc {
+	authenticate := h.Middleware(scope)
+	return func(c *gin.Context) {
+		if c.GetHeader(apiKeyHeader) == "" && bearerToken(c) == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// SessionMiddleware 仅接受会话令牌的鉴权中间件（如API密钥管理）
+func (h *AuthHandler) SessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, err := h.authService.Authenticate(c.Request.Context(), bearerToken(c))
+		if err != nil {
+			abortAuth(c, err)
+			return
+		}
+		c.Set(authAddrKey, addr)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey 读取请求体（读取后回填）并校验API密钥签名
+func (h *AuthHandler) authenticateAPIKey(c *gin.Context, apiKey, scope string) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return h.authService.AuthenticateAPIKey(c.Request.Context(), service.APIKeyAuthReq{
+		APIKey:    apiKey,
+		Timestamp: c.GetHeader(apiTimestampHeader),
+		Signature: c.GetHeader(apiSignatureHeader),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.RequestURI(),
+		Body:      body,
+		ClientIP:  c.ClientIP(),
+		Scope:     scope,
+	})
+}
+
+// abortAuth 鉴权失败：凭证无效返回401，无权访问返回403，超出频率限制返回429，其余返回500
+func abortAuth(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrUnauthorized), errors.Is(err, service.ErrInvalidAPIKey),
+		errors.Is(err, service.ErrInvalidSignature), errors.Is(err, service.ErrSignatureExpired), errors.Is(err, service.ErrSignatureReused):
+		status = http.StatusUnauthorized
+	case errors.Is(err, service.ErrAPIKeyForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrRateLimited):
+		status = http.StatusTooManyRequests
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"code": status,
+		"msg":  err.Error(),
+	})
+}
+
+// bearerToken 读取Authorization请求头中的Bearer令牌
+func bearerToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// checkAuthAddr 校验请求体中的地址与登录钱包地址一致，不一致时写入错误响应并返回false
+func checkAuthAddr(c *gin.Context, addr string) bool {
+	authAddr := c.GetString(authAddrKey)
+	if authAddr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  service.ErrUnauthorized.Error(),
+		})
+		return false
+	}
+	if !strings.EqualFold(authAddr, addr) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "请求地址与登录钱包地址不一致",
+		})
+		return false
+	}
+	return true
+}
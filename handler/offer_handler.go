@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"nft_trade/service"
+	"nft_trade/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateOffer 创建报价
+func (h *TradeHandler) CreateOffer(c *gin.Context) {
+	var req service.CreateOfferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.BuyerAddr) {
+		return
+	}
+
+	offerNo, err := h.tradeService.CreateOffer(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"offer_no": offerNo},
+	})
+}
+
+// CancelOffer 取消报价
+func (h *TradeHandler) CancelOffer(c *gin.Context) {
+	var req service.CancelOfferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.BuyerAddr) {
+		return
+	}
+
+	if err := h.tradeService.CancelOffer(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"offer_no": req.OfferNo},
+	})
+}
+
+// AcceptOffer 持有者接受报价
+func (h *TradeHandler) AcceptOffer(c *gin.Context) {
+	var req service.AcceptOfferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+
+	orderNo, err := h.tradeService.AcceptOffer(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": orderNo},
+	})
+}
+
+// GetOffers 查询报价列表
+func (h *TradeHandler) GetOffers(c *gin.Context) {
+	// 解析查询参数
+	nftAssetID, _ := strconv.ParseUint(c.Query("nft_asset_id"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	req := service.GetOffersReq{
+		OwnerAddr:  c.Query("owner_addr"),
+		BuyerAddr:  c.Query("buyer_addr"),
+		NFTAssetID: nftAssetID,
+		Page:       page,
+		PageSize:   pageSize,
+	}
+	if statusStr := c.Query("status"); statusStr != "" {
+		status, err := strconv.Atoi(statusStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "status参数错误",
+			})
+			return
+		}
+		req.Status = &status
+	}
+
+	offers, total, err := h.tradeService.GetOffers(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"list":      offers,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetBestCollectionOffers 查询合集最高报价
+func (h *TradeHandler) GetBestCollectionOffers(c *gin.Context) {
+	chainID, _ := strconv.Atoi(c.Query("chain_id"))
+	req := service.GetBestCollectionOffersReq{
+		ContractAddr: c.Query("contract_addr"),
+		ChainID:      chainID,
+	}
+
+	summaries, err := h.tradeService.GetBestCollectionOffers(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": summaries},
+	})
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"nft_trade/service"
+	"nft_trade/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateSignedSellOrder 创建出售订单（钱包签名）
+func (h *TradeHandler) CreateSignedSellOrder(c *gin.Context) {
+	var req service.SignedCreateSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	orderNo, err := h.tradeService.CreateSignedSellOrder(c.Request.Context(), req)
+	if err != nil {
+		status := signedErrorStatus(err)
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": orderNo},
+	})
+}
+
+// MatchSignedOrder 购买订单（钱包签名）
+func (h *TradeHandler) MatchSignedOrder(c *gin.Context) {
+	var req service.SignedMatchOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	orderNo, err := h.tradeService.MatchSignedOrder(c.Request.Context(), req)
+	if err != nil {
+		status := signedErrorStatus(err)
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": orderNo},
+	})
+}
+
+// CancelSignedSellOrder 取消出售订单（钱包签名）
+func (h *TradeHandler) CancelSignedSellOrder(c *gin.Context) {
+	var req service.SignedCancelSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	if err := h.tradeService.CancelSignedSellOrder(c.Request.Context(), req); err != nil {
+		status := signedErrorStatus(err)
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": req.OrderNo},
+	})
+}
+
+// CancelAllOrders 批量取消全部订单（递增订单计数器，钱包签名）
+func (h *TradeHandler) CancelAllOrders(c *gin.Context) {
+	var req service.CancelAllOrdersReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.tradeService.CancelAllOrders(c.Request.Context(), req)
+	if err != nil {
+		status := signedErrorStatus(err)
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// GetUserNonce 查询签名挂单计数器与nonce
+func (h *TradeHandler) GetUserNonce(c *gin.Context) {
+	userAddr := c.Query("user_addr")
+	if userAddr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "user_addr不能为空",
+		})
+		return
+	}
+
+	resp, err := h.tradeService.GetUserNonce(c.Request.Context(), userAddr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// signedErrorStatus 签名相关错误返回401，计数器/nonce冲突返回409，其余返回500
+func signedErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidSignature) || errors.Is(err, service.ErrSignatureExpired) || errors.Is(err, service.ErrSignatureReused) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, service.ErrCounterMismatch) || errors.Is(err, service.ErrNonceUsed) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
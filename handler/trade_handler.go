@@ -34,6 +34,9 @@ func (h *TradeHandler) CreateSellOrder(c *gin.Context) {
 		})
 		return
 	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
 
 	orderNo, err := h.tradeService.CreateSellOrder(c.Request.Context(), req)
 	if err != nil {
@@ -62,6 +65,9 @@ func (h *TradeHandler) MatchOrder(c *gin.Context) {
 		})
 		return
 	}
+	if !checkAuthAddr(c, req.BuyerAddr) {
+		return
+	}
 
 	orderNo, err := h.tradeService.MatchOrder(c.Request.Context(), req)
 	if err != nil {
@@ -79,6 +85,196 @@ func (h *TradeHandler) MatchOrder(c *gin.Context) {
 	})
 }
 
+// CancelSellOrder 取消出售订单（卖家下架）
+func (h *TradeHandler) CancelSellOrder(c *gin.Context) {
+	var req service.CancelSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+
+	if err := h.tradeService.CancelSellOrder(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": req.OrderNo},
+	})
+}
+
+// CreateBundleOrder 创建组合出售订单
+func (h *TradeHandler) CreateBundleOrder(c *gin.Context) {
+	var req service.CreateBundleOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+
+	orderNo, err := h.tradeService.CreateBundleOrder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": orderNo},
+	})
+}
+
+// BatchCreateSellOrder 批量创建出售订单
+func (h *TradeHandler) BatchCreateSellOrder(c *gin.Context) {
+	var req service.BatchCreateSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+
+	results, err := h.tradeService.BatchCreateSellOrder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": results},
+	})
+}
+
+// BatchCancelSellOrder 批量取消出售订单
+func (h *TradeHandler) BatchCancelSellOrder(c *gin.Context) {
+	var req service.BatchCancelSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+
+	results, err := h.tradeService.BatchCancelSellOrder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": results},
+	})
+}
+
+// UpdateSellOrder 修改出售订单（改价/修改结束时间）
+func (h *TradeHandler) UpdateSellOrder(c *gin.Context) {
+	var req service.UpdateSellOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Logger.Error("参数绑定失败", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	if !checkAuthAddr(c, req.SellerAddr) {
+		return
+	}
+	req.OrderNo = c.Param("order_no")
+
+	if err := h.tradeService.UpdateSellOrder(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"order_no": req.OrderNo},
+	})
+}
+
+// GetPriceHistory 查询订单改价历史
+func (h *TradeHandler) GetPriceHistory(c *gin.Context) {
+	histories, err := h.tradeService.GetPriceHistory(c.Request.Context(), c.Param("order_no"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": histories},
+	})
+}
+
+// GetOrderDetail 查询订单详情（含状态时间线）
+func (h *TradeHandler) GetOrderDetail(c *gin.Context) {
+	detail, err := h.tradeService.GetOrderDetail(c.Request.Context(), c.Param("order_no"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": detail,
+	})
+}
+
 // GetTradeRecords 查询交易记录
 func (h *TradeHandler) GetTradeRecords(c *gin.Context) {
 	// 解析查询参数
@@ -125,3 +321,48 @@ func (h *TradeHandler) GetTradeRecords(c *gin.Context) {
 		},
 	})
 }
+
+// ListOrders 查询挂单列表（含合集地板价）
+func (h *TradeHandler) ListOrders(c *gin.Context) {
+	// 解析查询参数
+	chainID, _ := strconv.Atoi(c.Query("chain_id"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	req := service.ListOrdersReq{
+		ContractAddr: c.Query("contract_addr"),
+		ChainID:      chainID,
+		SellerAddr:   c.Query("seller_addr"),
+		ViewerAddr:   c.GetString(authAddrKey), // 取自登录会话（未登录为空），不采信查询参数
+		MinPrice:     c.Query("min_price"),
+		MaxPrice:     c.Query("max_price"),
+		SortBy:       c.Query("sort_by"),
+		SortOrder:    c.Query("sort_order"),
+		Cursor:       c.Query("cursor"),
+		Limit:        limit,
+	}
+	if statusStr := c.Query("status"); statusStr != "" {
+		status, err := strconv.Atoi(statusStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "status参数错误",
+			})
+			return
+		}
+		req.Status = &status
+	}
+
+	resp, err := h.tradeService.ListOrders(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": resp,
+	})
+}
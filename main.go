@@ -38,9 +38,20 @@ func main() {
 	// 自动迁移表结构（开发环境）
 	err = db.AutoMigrate(
 		&model.NFTAsset{},
+		&model.NFTAssetBalance{},
 		&model.NFTOrder{},
 		&model.NFTAssetLock{},
 		&model.NFTTradeRecord{},
+		&model.NFTBid{},
+		&model.NFTOffer{},
+		&model.NFTOrderPriceHistory{},
+		&model.NFTOrderItem{},
+		&model.NFTTradeRecordItem{},
+		&model.NFTOrderStatusHistory{},
+		&model.NFTUserCounter{},
+		&model.NFTUserNonce{},
+		&model.NFTAPIKey{},
+		&model.NFTIndexerCheckpoint{},
 	)
 	if err != nil {
 		utils.Logger.Fatal("迁移表结构失败", zap.Error(err))
@@ -55,9 +66,14 @@ func main() {
 	}
 	defer utils.CloseRabbitMQ()
 
-	// 6. 初始化服务和处理器
+	// 6. 初始化市场操作员签名器（解锁keystore）、服务和处理器
+	if err := service.InitOperatorSigners(); err != nil {
+		utils.Logger.Fatal("初始化操作员签名器失败", zap.Error(err))
+	}
 	tradeService := service.NewTradeService(db)
 	tradeHandler := handler.NewTradeHandler(tradeService)
+	authService := service.NewAuthService(db)
+	authHandler := handler.NewAuthHandler(authService)
 
 	// 7. 启动RabbitMQ消费者（处理交易执行消息）
 	err = utils.ConsumeTradeMsg(func(orderNo string) error {
@@ -67,15 +83,82 @@ func main() {
 		utils.Logger.Fatal("启动消费者失败", zap.Error(err))
 	}
 
+	// 启动过期订单清理任务
+	workerCtx, cancelWorker := context.WithCancel(context.Background())
+	defer cancelWorker()
+	expireWorker := service.NewExpireWorker(db, config.GlobalConfig.OrderExpireInterval, config.GlobalConfig.OrderExpireBatchSize)
+	go expireWorker.Start(workerCtx)
+
+	// 启动定时挂单开售任务
+	listingScheduler := service.NewListingScheduler(db, config.GlobalConfig.ListingLiveInterval, config.GlobalConfig.OrderExpireBatchSize)
+	go listingScheduler.Start(workerCtx)
+
+	// 启动Transfer事件索引任务（同步链上持有者，下架失效订单）
+	transferIndexer := service.NewTransferIndexer(db, config.GlobalConfig.IndexerInterval, config.GlobalConfig.IndexerBatchBlocks)
+	go transferIndexer.Start(workerCtx)
+
+	// 启动交割交易确认任务（达到确认区块数后完成交割，处理链重组）
+	confirmWorker := service.NewConfirmWorker(db, config.GlobalConfig.ConfirmInterval, config.GlobalConfig.OrderExpireBatchSize,
+		config.GlobalConfig.ConfirmMaxResubmits, config.GlobalConfig.ConfirmMissingThreshold)
+	go confirmWorker.Start(workerCtx)
+
 	// 8. 初始化Gin引擎
 	r := gin.Default()
+	// 仅信任配置的反向代理转发的客户端IP（API Key IP白名单依赖ClientIP，默认信任全部代理会被伪造请求头绕过）
+	if err := r.SetTrustedProxies(config.GlobalConfig.TrustedProxies); err != nil {
+		utils.Logger.Fatal("受信任代理配置错误", zap.Error(err))
+	}
 
-	// 路由
+	// 钱包登录（Sign-In With Ethereum）
+	auth := r.Group("/api/v1/auth")
+	{
+		auth.GET("/nonce", authHandler.GetNonce) // 获取登录nonce
+		auth.POST("/verify", authHandler.Verify) // 校验签名并签发会话
+		auth.POST("/logout", authHandler.Logout) // 注销会话
+
+		// API密钥（交易机器人HMAC签名鉴权，创建/吊销须使用登录会话）
+		auth.POST("/apikeys", authHandler.SessionMiddleware(), authHandler.CreateAPIKey)         // 创建API密钥
+		auth.GET("/apikeys", authHandler.Middleware(service.ScopeRead), authHandler.ListAPIKeys) // 查询API密钥列表
+		auth.DELETE("/apikeys/:id", authHandler.SessionMiddleware(), authHandler.DeleteAPIKey)   // 吊销API密钥
+	}
+
+	// 路由（写接口须登录会话或API密钥，请求体中的地址须与登录钱包地址一致；签名接口以请求签名鉴权）
+	tradeAuth := authHandler.Middleware(service.ScopeTrade)
+	cancelAuth := authHandler.Middleware(service.ScopeCancel)
+	viewerAuth := authHandler.OptionalMiddleware(service.ScopeRead) // 公开查询，登录后识别当前用户
 	v1 := r.Group("/api/v1/trade")
 	{
-		v1.POST("/sell", tradeHandler.CreateSellOrder)   // 创建出售订单
-		v1.POST("/match", tradeHandler.MatchOrder)       // 购买订单
-		v1.GET("/records", tradeHandler.GetTradeRecords) // 查询交易记录
+		v1.POST("/sell", tradeAuth, tradeHandler.CreateSellOrder)               // 创建出售订单
+		v1.POST("/match", tradeAuth, tradeHandler.MatchOrder)                   // 购买订单
+		v1.POST("/cancel", cancelAuth, tradeHandler.CancelSellOrder)            // 取消出售订单
+		v1.POST("/sell/signed", tradeHandler.CreateSignedSellOrder)             // 创建出售订单（钱包签名）
+		v1.POST("/match/signed", tradeHandler.MatchSignedOrder)                 // 购买订单（钱包签名）
+		v1.POST("/cancel/signed", tradeHandler.CancelSignedSellOrder)           // 取消出售订单（钱包签名）
+		v1.POST("/cancel/all", tradeHandler.CancelAllOrders)                    // 批量取消全部订单（递增订单计数器）
+		v1.GET("/nonce", tradeHandler.GetUserNonce)                             // 查询签名挂单计数器与nonce
+		v1.POST("/sell/batch", tradeAuth, tradeHandler.BatchCreateSellOrder)    // 批量创建出售订单
+		v1.POST("/sell/bundle", tradeAuth, tradeHandler.CreateBundleOrder)      // 创建组合出售订单
+		v1.POST("/cancel/batch", cancelAuth, tradeHandler.BatchCancelSellOrder) // 批量取消出售订单
+		v1.PATCH("/order/:order_no", tradeAuth, tradeHandler.UpdateSellOrder)   // 修改出售订单（改价）
+		v1.GET("/order/:order_no", tradeHandler.GetOrderDetail)                 // 查询订单详情（含状态时间线）
+		v1.GET("/order/:order_no/price-history", tradeHandler.GetPriceHistory)  // 查询改价历史
+		v1.GET("/records", tradeHandler.GetTradeRecords)                        // 查询交易记录
+		v1.GET("/orders", viewerAuth, tradeHandler.ListOrders)                  // 查询挂单列表（含合集地板价，登录后含本人私人挂单）
+
+		// 英式拍卖
+		v1.POST("/auction/bid", tradeAuth, tradeHandler.PlaceBid)   // 拍卖出价
+		v1.GET("/auction/bids", tradeHandler.GetAuctionBids)        // 查询拍卖出价列表
+		v1.GET("/auction/bids/history", tradeHandler.GetBidHistory) // 查询用户出价历史
+
+		// 荷兰式拍卖
+		v1.GET("/dutch/price", tradeHandler.GetDutchPrice) // 查询当前价格
+
+		// 买家报价
+		v1.POST("/offer", tradeAuth, tradeHandler.CreateOffer)         // 创建报价
+		v1.POST("/offer/cancel", cancelAuth, tradeHandler.CancelOffer) // 取消报价
+		v1.POST("/offer/accept", tradeAuth, tradeHandler.AcceptOffer)  // 持有者接受报价
+		v1.GET("/offers", tradeHandler.GetOffers)                      // 查询报价列表
+		v1.GET("/offers/best", tradeHandler.GetBestCollectionOffers)   // 查询合集最高报价
 	}
 
 	// 9. 启动服务（优雅关闭）
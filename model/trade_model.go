@@ -6,40 +6,201 @@ import (
 	"gorm.io/gorm"
 )
 
+// NFT代币标准
+const (
+	TokenStandardERC721  = 0
+	TokenStandardERC1155 = 1
+)
+
 // NFTAsset NFT资产表（关联交易模块）
 type NFTAsset struct {
-	ID           uint64         `gorm:"primaryKey;comment:资产ID"`
-	TokenID      string         `gorm:"uniqueIndex;comment:链上TokenID"`
-	ContractAddr string         `gorm:"comment:NFT合约地址"`
-	OwnerAddr    string         `gorm:"comment:当前持有者钱包地址"`
-	MetadataCID  string         `gorm:"comment:IPFS元数据CID"`
-	ChainID      int            `gorm:"comment:所属链ID"`
-	Status       int            `gorm:"comment:0-正常 1-已销毁 2-冻结"`
-	CreatedAt    time.Time      `gorm:"comment:创建时间"`
-	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
-	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
+	ID            uint64         `gorm:"primaryKey;comment:资产ID"`
+	TokenID       string         `gorm:"type:varchar(128);uniqueIndex:idx_chain_contract_token,priority:3;comment:链上TokenID（同一合约内唯一）"`
+	ContractAddr  string         `gorm:"type:varchar(64);uniqueIndex:idx_chain_contract_token,priority:2;comment:NFT合约地址"`
+	TokenStandard int            `gorm:"comment:代币标准 0-ERC721 1-ERC1155"`
+	OwnerAddr     string         `gorm:"comment:当前持有者钱包地址（ERC-1155不使用，持有量见NFTAssetBalance）"`
+	MetadataCID   string         `gorm:"comment:IPFS元数据CID"`
+	ChainID       int            `gorm:"uniqueIndex:idx_chain_contract_token,priority:1;comment:所属链ID"`
+	Status        int            `gorm:"comment:0-正常 1-已销毁 2-冻结"`
+	CreatedAt     time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt     time.Time      `gorm:"comment:更新时间"`
+	DeletedAt     gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// NFTAssetBalance ERC-1155持有量表（每个持有者一条）
+type NFTAssetBalance struct {
+	ID         uint64    `gorm:"primaryKey;comment:记录ID"`
+	NFTAssetID uint64    `gorm:"uniqueIndex:idx_asset_owner;comment:关联NFT资产ID"`
+	OwnerAddr  string    `gorm:"type:varchar(64);uniqueIndex:idx_asset_owner;comment:持有者钱包地址"`
+	Balance    int64     `gorm:"comment:持有数量"`
+	LockedQty  int64     `gorm:"comment:挂单锁定数量（可售数量为Balance-LockedQty）"`
+	CreatedAt  time.Time `gorm:"comment:创建时间"`
+	UpdatedAt  time.Time `gorm:"comment:更新时间"`
+}
+
+// NFTOrderStatus NFT订单状态
+type NFTOrderStatus int
+
+const (
+	NFTOrderStatusNew        NFTOrderStatus = -1 // 订单创建前（仅用于状态变更记录）
+	NFTOrderStatusPending    NFTOrderStatus = 0  // 待成交
+	NFTOrderStatusCompleted  NFTOrderStatus = 1  // 已成交
+	NFTOrderStatusCancelled  NFTOrderStatus = 2  // 已取消
+	NFTOrderStatusExpired    NFTOrderStatus = 3  // 已过期
+	NFTOrderStatusProcessing NFTOrderStatus = 4  // 处理中
+	NFTOrderStatusFailed     NFTOrderStatus = 5  // 失败
+	NFTOrderStatusConfirming NFTOrderStatus = 6  // 确认中（交割交易已广播，等待打包并达到确认区块数）
+)
+
+// String 状态名称
+func (s NFTOrderStatus) String() string {
+	switch s {
+	case NFTOrderStatusNew:
+		return "新建"
+	case NFTOrderStatusPending:
+		return "待成交"
+	case NFTOrderStatusCompleted:
+		return "已成交"
+	case NFTOrderStatusCancelled:
+		return "已取消"
+	case NFTOrderStatusExpired:
+		return "已过期"
+	case NFTOrderStatusProcessing:
+		return "处理中"
+	case NFTOrderStatusFailed:
+		return "失败"
+	case NFTOrderStatusConfirming:
+		return "确认中"
+	default:
+		return "未知"
+	}
 }
 
 // NFTOrder NFT订单表（核心）
 type NFTOrder struct {
-	ID           uint64         `gorm:"primaryKey;comment:订单ID"`
-	OrderNo      string         `gorm:"uniqueIndex;comment:订单编号（UUID）"`
-	NFTAssetID   uint64         `gorm:"comment:关联NFT资产ID（外键）"`
+	ID            uint64         `gorm:"primaryKey;comment:订单ID"`
+	OrderNo       string         `gorm:"uniqueIndex;comment:订单编号（UUID）"`
+	NFTAssetID    uint64         `gorm:"comment:关联NFT资产ID（外键，组合订单为0）"`
+	TokenID       string         `gorm:"comment:链上TokenID（组合订单为空）"`
+	ContractAddr  string         `gorm:"comment:NFT合约地址（组合订单跨合约时为空）"`
+	TokenStandard int            `gorm:"comment:代币标准 0-ERC721 1-ERC1155"`
+	IsBundle      bool           `gorm:"comment:是否为组合订单（多个NFT一口价出售，明细见NFTOrderItem）"`
+	Quantity      int64          `gorm:"default:1;comment:挂单数量（ERC-721为1）"`
+	FilledQty     int64          `gorm:"comment:已售出数量（ERC-1155部分成交，含交割中）"`
+	ParentOrderNo string         `gorm:"index;comment:ERC-1155部分成交子订单所属挂单编号（挂单本身为空）"`
+	SellerAddr    string         `gorm:"comment:卖家钱包地址"`
+	BuyerAddr     string         `gorm:"comment:买家钱包地址（未成交则为空）"`
+	ReservedBuyer string         `gorm:"index;comment:指定买家钱包地址（私人挂单，空表示公开挂单）"`
+	Price         string         `gorm:"comment:交易价格（wei单位，ERC-1155挂单为单价，子订单为总价）"`
+	DealPrice     string         `gorm:"comment:成交价格（wei单位，拍卖为中标价，空则取Price）"`
+	OrderType     int            `gorm:"comment:0-一口价 1-英式拍卖 2-荷兰式拍卖"`
+	ReservePrice  string         `gorm:"comment:英式拍卖保留价（wei单位，空表示无保留价）"`
+	MinIncrement  string         `gorm:"comment:英式拍卖最小加价幅度（wei单位）"`
+	EndPrice      string         `gorm:"comment:荷兰式拍卖结束价（wei单位，Price为起始价）"`
+	DecayCurve    int            `gorm:"comment:荷兰式拍卖降价曲线 0-线性 1-指数"`
+	Status        NFTOrderStatus `gorm:"comment:0-待成交 1-已成交 2-已取消 3-已过期 4-处理中 5-失败 6-确认中"`
+	ChainID       int            `gorm:"comment:所属链ID"`
+	StartTime     time.Time      `gorm:"comment:订单开始时间（定时挂单为开售时间）"`
+	PendingLive   bool           `gorm:"index;comment:定时挂单是否待发布开售事件"`
+	EndTime       time.Time      `gorm:"comment:订单结束时间"`
+	Currency      string         `gorm:"comment:计价代币地址（EIP-712签名挂单，零地址表示原生代币）"`
+	Salt          string         `gorm:"comment:EIP-712签名随机盐"`
+	Nonce         uint64         `gorm:"comment:EIP-712签名卖家nonce"`
+	Counter       uint64         `gorm:"comment:EIP-712签名时卖家的订单计数器（计数器递增后失效）"`
+	Signature     string         `gorm:"type:varchar(256);comment:卖家EIP-712签名（空表示未签名订单）"`
+	Version       int64          `gorm:"default:0;comment:乐观锁版本号（卖家修改价格/结束时间时递增）"`
+	CreatedAt     time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt     time.Time      `gorm:"comment:更新时间"`
+	DeletedAt     gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// NFTOrderItem 组合订单明细表
+type NFTOrderItem struct {
+	ID           uint64         `gorm:"primaryKey;comment:明细ID"`
+	OrderNo      string         `gorm:"index;comment:关联订单编号"`
+	NFTAssetID   uint64         `gorm:"index;comment:关联NFT资产ID"`
 	TokenID      string         `gorm:"comment:链上TokenID"`
 	ContractAddr string         `gorm:"comment:NFT合约地址"`
-	SellerAddr   string         `gorm:"comment:卖家钱包地址"`
-	BuyerAddr    string         `gorm:"comment:买家钱包地址（未成交则为空）"`
-	Price        string         `gorm:"comment:交易价格（wei单位）"`
-	OrderType    int            `gorm:"comment:0-一口价 1-英式拍卖 2-荷兰式拍卖"`
-	Status       int            `gorm:"comment:0-待成交 1-已成交 2-已取消 3-已过期 4-处理中 5-失败"`
-	ChainID      int            `gorm:"comment:所属链ID"`
-	StartTime    time.Time      `gorm:"comment:订单开始时间"`
-	EndTime      time.Time      `gorm:"comment:订单结束时间"`
 	CreatedAt    time.Time      `gorm:"comment:创建时间"`
 	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
 	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
 }
 
+// NFTOrderPriceHistory 订单改价历史表
+type NFTOrderPriceHistory struct {
+	ID           uint64         `gorm:"primaryKey;comment:记录ID"`
+	OrderNo      string         `gorm:"index;comment:关联订单编号"`
+	OldPrice     string         `gorm:"comment:修改前价格（wei单位）"`
+	NewPrice     string         `gorm:"comment:修改后价格（wei单位）"`
+	OldEndTime   time.Time      `gorm:"comment:修改前结束时间"`
+	NewEndTime   time.Time      `gorm:"comment:修改后结束时间"`
+	OperatorAddr string         `gorm:"comment:操作人钱包地址"`
+	CreatedAt    time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// NFTOrderStatusHistory 订单状态变更历史表
+type NFTOrderStatusHistory struct {
+	ID         uint64         `gorm:"primaryKey;comment:记录ID"`
+	OrderNo    string         `gorm:"index;comment:关联订单编号"`
+	FromStatus NFTOrderStatus `gorm:"comment:变更前状态（-1表示订单创建）"`
+	ToStatus   NFTOrderStatus `gorm:"comment:变更后状态"`
+	Operator   string         `gorm:"comment:操作方（用户钱包地址或system:任务名）"`
+	Reason     string         `gorm:"comment:变更原因"`
+	CreatedAt  time.Time      `gorm:"comment:变更时间"`
+}
+
+// TableName 表名
+func (h *NFTOrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// NFTUserCounter 用户订单计数器表（EIP-712签名挂单，递增即批量作废此前签名的订单）
+type NFTUserCounter struct {
+	ID        uint64    `gorm:"primaryKey;comment:记录ID"`
+	UserAddr  string    `gorm:"uniqueIndex;comment:用户钱包地址（小写）"`
+	Counter   uint64    `gorm:"comment:当前计数器"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+	UpdatedAt time.Time `gorm:"comment:更新时间"`
+}
+
+// NFTUserNonce 用户已使用的签名nonce表（防止同一签名重复挂单）
+type NFTUserNonce struct {
+	ID        uint64    `gorm:"primaryKey;comment:记录ID"`
+	UserAddr  string    `gorm:"uniqueIndex:idx_user_nonce;comment:用户钱包地址（小写）"`
+	Nonce     uint64    `gorm:"uniqueIndex:idx_user_nonce;comment:签名nonce"`
+	OrderNo   string    `gorm:"comment:使用该nonce的订单编号"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+}
+
+// NFTAPIKey 用户API密钥表（交易机器人HMAC签名鉴权，删除即吊销）
+type NFTAPIKey struct {
+	ID           uint64         `gorm:"primaryKey;comment:密钥ID"`
+	UserAddr     string         `gorm:"index;comment:绑定的钱包地址"`
+	Name         string         `gorm:"comment:密钥名称"`
+	KeyHash      string         `gorm:"type:varchar(64);uniqueIndex;comment:API Key的SHA-256哈希"`
+	KeyPrefix    string         `gorm:"comment:API Key前缀（展示用）"`
+	SecretCipher string         `gorm:"type:varchar(256);comment:HMAC密钥密文（AES-GCM）"`
+	Scopes       string         `gorm:"comment:权限范围，逗号分隔（read/trade/cancel）"`
+	IPAllowlist  string         `gorm:"type:varchar(1024);comment:IP白名单，逗号分隔的IP或CIDR（空表示不限）"`
+	RateLimit    int            `gorm:"comment:每分钟请求上限"`
+	ExpiresAt    *time.Time     `gorm:"comment:过期时间（null表示永不过期）"`
+	LastUsedAt   *time.Time     `gorm:"comment:最近使用时间"`
+	CreatedAt    time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// NFTIndexerCheckpoint 链上事件索引进度表（每条链一条）
+type NFTIndexerCheckpoint struct {
+	ID        uint64    `gorm:"primaryKey;comment:记录ID"`
+	ChainID   int       `gorm:"uniqueIndex;comment:链ID"`
+	LastBlock uint64    `gorm:"comment:已索引的最后区块高度"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+	UpdatedAt time.Time `gorm:"comment:更新时间"`
+}
+
 // NFTAssetLock NFT资产锁定表（防止重复挂单）
 type NFTAssetLock struct {
 	ID         uint64         `gorm:"primaryKey;comment:锁定ID"`
@@ -53,25 +214,110 @@ type NFTAssetLock struct {
 	DeletedAt  gorm.DeletedAt `gorm:"index;comment:删除时间"`
 }
 
-// NFTTradeRecord NFT交易记录表（最终账本）
-type NFTTradeRecord struct {
-	ID         uint64         `gorm:"primaryKey;comment:交易记录ID"`
-	TradeNo    string         `gorm:"uniqueIndex;comment:交易编号（UUID）"`
-	OrderNo    string         `gorm:"comment:关联订单编号"`
+// 拍卖出价状态（英式拍卖出价逻辑使用）
+// 出价不冻结资金，中标出价在链上交割时付款；被超越、流拍、已取消的出价即为已释放，无资金需要退还
+const (
+	BidStatusLeading   = 0 // 领先
+	BidStatusOutbid    = 1 // 被超越
+	BidStatusWon       = 2 // 中标
+	BidStatusUnsold    = 3 // 流拍
+	BidStatusCancelled = 4 // 已取消
+)
+
+// NFTBid 英式拍卖出价表
+type NFTBid struct {
+	ID         uint64         `gorm:"primaryKey;comment:出价ID"`
+	BidNo      string         `gorm:"uniqueIndex;comment:出价编号（UUID）"`
+	OrderNo    string         `gorm:"index;comment:关联订单编号"`
 	NFTAssetID uint64         `gorm:"comment:关联NFT资产ID"`
-	SellerAddr string         `gorm:"comment:卖家钱包地址"`
-	BuyerAddr  string         `gorm:"comment:买家钱包地址"`
-	Price      string         `gorm:"comment:交易价格"`
-	Fee        string         `gorm:"comment:平台手续费"`
-	FeeAddr    string         `gorm:"comment:手续费接收地址"`
-	TxHash     string         `gorm:"comment:链上交易哈希（NFT转账）"`
-	ChainID    int            `gorm:"comment:所属链ID"`
-	TradeTime  time.Time      `gorm:"comment:交易完成时间"`
+	BidderAddr string         `gorm:"index;comment:出价人钱包地址"`
+	Amount     string         `gorm:"comment:出价金额（wei单位）"`
+	Status     int            `gorm:"comment:0-领先 1-被超越（已释放） 2-中标 3-流拍（已释放） 4-已取消（已释放）"`
+	BidTime    time.Time      `gorm:"comment:出价时间"`
 	CreatedAt  time.Time      `gorm:"comment:创建时间"`
 	UpdatedAt  time.Time      `gorm:"comment:更新时间"`
 	DeletedAt  gorm.DeletedAt `gorm:"index;comment:删除时间"`
 }
 
+// 报价状态
+const (
+	OfferStatusPending   = 0 // 待接受
+	OfferStatusAccepted  = 1 // 已接受（全部成交）
+	OfferStatusCancelled = 2 // 已取消
+	OfferStatusExpired   = 3 // 已过期
+)
+
+// NFTOffer 买家报价表（对单个NFT、整个合集或指定特征出价，由持有者接受）
+type NFTOffer struct {
+	ID           uint64         `gorm:"primaryKey;comment:报价ID"`
+	OfferNo      string         `gorm:"uniqueIndex;comment:报价编号（UUID）"`
+	OfferType    int            `gorm:"comment:0-单个NFT 1-合集 2-特征"`
+	NFTAssetID   uint64         `gorm:"index;comment:关联NFT资产ID（合集/特征报价为0）"`
+	TokenID      string         `gorm:"comment:链上TokenID（合集/特征报价为空）"`
+	ContractAddr string         `gorm:"index;comment:NFT合约地址"`
+	TraitType    string         `gorm:"comment:特征类型（特征报价有效，如Background）"`
+	TraitValue   string         `gorm:"comment:特征值（特征报价有效，如Gold）"`
+	BuyerAddr    string         `gorm:"index;comment:买家钱包地址"`
+	Price        string         `gorm:"comment:单个NFT报价金额（wei单位）"`
+	Quantity     int            `gorm:"comment:求购数量"`
+	FilledQty    int            `gorm:"comment:已成交数量"`
+	ChainID      int            `gorm:"comment:所属链ID"`
+	Status       int            `gorm:"comment:0-待接受 1-已接受（全部成交） 2-已取消 3-已过期"`
+	OrderNo      string         `gorm:"comment:最近一次接受生成的订单编号"`
+	ExpireTime   time.Time      `gorm:"comment:报价过期时间"`
+	CreatedAt    time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// 交易记录确认状态（0为已确认，兼容新增字段前的历史记录）
+const (
+	TradeConfirmStatusConfirmed  = 0
+	TradeConfirmStatusConfirming = 1
+	TradeConfirmStatusFailed     = 2
+)
+
+// NFTTradeRecord NFT交易记录表（最终账本）
+type NFTTradeRecord struct {
+	ID            uint64         `gorm:"primaryKey;comment:交易记录ID"`
+	TradeNo       string         `gorm:"uniqueIndex;comment:交易编号（UUID）"`
+	OrderNo       string         `gorm:"comment:关联订单编号"`
+	NFTAssetID    uint64         `gorm:"comment:关联NFT资产ID（组合交易为0）"`
+	IsBundle      bool           `gorm:"comment:是否为组合交易（明细见NFTTradeRecordItem）"`
+	ItemCount     int            `gorm:"comment:成交NFT数量"`
+	Quantity      int64          `gorm:"default:1;comment:成交数量（ERC-1155为成交份数，ERC-721为1）"`
+	SellerAddr    string         `gorm:"comment:卖家钱包地址"`
+	BuyerAddr     string         `gorm:"comment:买家钱包地址"`
+	Price         string         `gorm:"comment:交易价格"`
+	Fee           string         `gorm:"comment:平台手续费"`
+	FeeAddr       string         `gorm:"comment:手续费接收地址"`
+	TxHash        string         `gorm:"comment:链上交易哈希（NFT转账）"`
+	TxNonce       uint64         `gorm:"comment:交割交易nonce（市场操作员账户）"`
+	RawTx         string         `gorm:"type:text;comment:已签名原始交易（十六进制，交易丢失时原样重新广播）"`
+	ChainID       int            `gorm:"comment:所属链ID"`
+	BlockNumber   uint64         `gorm:"comment:交易所在区块高度（未打包为0）"`
+	BlockHash     string         `gorm:"comment:交易所在区块哈希（链重组后可能变化）"`
+	ConfirmStatus int            `gorm:"index;comment:确认状态 0-已确认 1-确认中 2-失败"`
+	MissingCount  int            `gorm:"comment:交易连续未查询到次数（达到阈值才重新广播）"`
+	ResubmitCount int            `gorm:"comment:交易丢失后原样重新广播次数"`
+	TradeTime     time.Time      `gorm:"comment:交易完成时间（确认后更新为确认时间）"`
+	CreatedAt     time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt     time.Time      `gorm:"comment:更新时间"`
+	DeletedAt     gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
+// NFTTradeRecordItem 组合交易明细表
+type NFTTradeRecordItem struct {
+	ID           uint64         `gorm:"primaryKey;comment:明细ID"`
+	TradeNo      string         `gorm:"index;comment:关联交易编号"`
+	NFTAssetID   uint64         `gorm:"index;comment:关联NFT资产ID"`
+	TokenID      string         `gorm:"comment:链上TokenID"`
+	ContractAddr string         `gorm:"comment:NFT合约地址"`
+	CreatedAt    time.Time      `gorm:"comment:创建时间"`
+	UpdatedAt    time.Time      `gorm:"comment:更新时间"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;comment:删除时间"`
+}
+
 // Trade 交易记录模型
 type Trade struct {
 	ID            string    `gorm:"primary_key;column:id" json:"id"`             // 交易ID
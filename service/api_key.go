@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// API密钥权限范围
+const (
+	ScopeRead   = "read"   // 只读查询
+	ScopeTrade  = "trade"  // 挂单、购买、出价、报价
+	ScopeCancel = "cancel" // 取消挂单/报价
+)
+
+// apiKeyScopes 全部权限范围
+var apiKeyScopes = []string{ScopeRead, ScopeTrade, ScopeCancel}
+
+// apiKeyTimestampWindow 请求时间戳与服务器时间的最大偏差
+const apiKeyTimestampWindow = 30 * time.Second
+
+// apiKeyPrefix API Key前缀（便于识别及泄露扫描）
+const apiKeyPrefix = "nftk_"
+
+var (
+	// ErrInvalidAPIKey API Key不存在、已吊销或已过期
+	ErrInvalidAPIKey = errors.New("API Key无效或已过期")
+	// ErrAPIKeyForbidden 请求IP不在白名单或权限范围不足
+	ErrAPIKeyForbidden = errors.New("API Key无权访问")
+	// ErrRateLimited 超出API Key请求频率限制
+	ErrRateLimited = errors.New("请求过于频繁")
+)
+
+// -------------- 请求结构体 --------------
+// CreateAPIKeyReq 创建API密钥请求
+type CreateAPIKeyReq struct {
+	UserAddr    string     `json:"-"` // 取自登录会话
+	Name        string     `json:"name" binding:"required"`
+	Scopes      []string   `json:"scopes" binding:"required"` // read/trade/cancel
+	IPAllowlist []string   `json:"ip_allowlist"`              // 可选，IP或CIDR，为空不限
+	RateLimit   int        `json:"rate_limit"`                // 可选，每分钟请求上限，默认取配置
+	ExpiresAt   *time.Time `json:"expires_at"`                // 可选，过期时间
+}
+
+// APIKeyInfo API密钥信息（不含密钥原文）
+type APIKeyInfo struct {
+	ID          uint64     `json:"id"`
+	Name        string     `json:"name"`
+	KeyPrefix   string     `json:"key_prefix"`
+	Scopes      []string   `json:"scopes"`
+	IPAllowlist []string   `json:"ip_allowlist"`
+	RateLimit   int        `json:"rate_limit"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResp 创建API密钥结果（API Key与Secret仅返回一次）
+type CreateAPIKeyResp struct {
+	APIKeyInfo
+	APIKey string `json:"api_key"`
+	Secret string `json:"secret"`
+}
+
+// APIKeyAuthReq API密钥请求鉴权参数
+type APIKeyAuthReq struct {
+	APIKey    string
+	Timestamp string // Unix毫秒
+	Signature string // 十六进制HMAC-SHA256，见APIKeySignPayload
+	Method    string
+	Path      string // 含查询参数
+	Body      []byte
+	ClientIP  string
+	Scope     string // 接口所需权限范围
+}
+
+// -------------- 核心方法 --------------
+// CreateAPIKey 为登录钱包创建API密钥，API Key仅存哈希，HMAC密钥加密存储
+func (s *authService) CreateAPIKey(ctx context.Context, req CreateAPIKeyReq) (*CreateAPIKeyResp, error) {
+	// 1. 校验参数
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("权限范围不能为空")
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, fmt.Errorf("不支持的权限范围：%s", scope)
+		}
+	}
+	for _, entry := range req.IPAllowlist {
+		if _, err := parseIPEntry(entry); err != nil {
+			return nil, fmt.Errorf("IP白名单格式错误：%s", entry)
+		}
+	}
+	if req.RateLimit == 0 {
+		req.RateLimit = config.GlobalConfig.APIKeyDefaultRateLimit
+	}
+	if req.RateLimit < 0 || req.RateLimit > config.GlobalConfig.APIKeyMaxRateLimit {
+		return nil, fmt.Errorf("请求上限须在1~%d次/分钟之间", config.GlobalConfig.APIKeyMaxRateLimit)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("过期时间须晚于当前时间")
+	}
+
+	// 2. 生成API Key与HMAC密钥
+	keyRand, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	apiKey := apiKeyPrefix + keyRand
+	secretCipher, err := utils.EncryptSecret(config.GlobalConfig.APIKeyEncryptionKey, secret)
+	if err != nil {
+		utils.Logger.Error("加密API密钥失败", zap.Error(err))
+		return nil, err
+	}
+
+	// 3. 落库
+	key := model.NFTAPIKey{
+		UserAddr:     req.UserAddr,
+		Name:         req.Name,
+		KeyHash:      hashAPIKey(apiKey),
+		KeyPrefix:    apiKey[:len(apiKeyPrefix)+8],
+		SecretCipher: secretCipher,
+		Scopes:       strings.Join(req.Scopes, ","),
+		IPAllowlist:  strings.Join(req.IPAllowlist, ","),
+		RateLimit:    req.RateLimit,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(&key).Error; err != nil {
+		utils.Logger.Error("创建API密钥失败", zap.String("user_addr", req.UserAddr), zap.Error(err))
+		return nil, err
+	}
+
+	utils.Logger.Info("API密钥已创建", zap.String("user_addr", req.UserAddr), zap.String("key_prefix", key.KeyPrefix))
+	return &CreateAPIKeyResp{
+		APIKeyInfo: toAPIKeyInfo(key),
+		APIKey:     apiKey,
+		Secret:     secret,
+	}, nil
+}
+
+// ListAPIKeys 查询钱包的API密钥列表
+func (s *authService) ListAPIKeys(ctx context.Context, userAddr string) ([]APIKeyInfo, error) {
+	var keys []model.NFTAPIKey
+	if err := s.db.WithContext(ctx).Where("user_addr = ?", userAddr).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	list := make([]APIKeyInfo, len(keys))
+	for i, key := range keys {
+		list[i] = toAPIKeyInfo(key)
+	}
+	return list, nil
+}
+
+// DeleteAPIKey 吊销API密钥（仅限本人）
+func (s *authService) DeleteAPIKey(ctx context.Context, userAddr string, id uint64) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_addr = ?", id, userAddr).Delete(&model.NFTAPIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("API密钥不存在")
+	}
+	utils.Logger.Info("API密钥已吊销", zap.String("user_addr", userAddr), zap.Uint64("id", id))
+	return nil
+}
+
+// AuthenticateAPIKey 校验API Key、IP白名单、权限范围、HMAC签名及频率限制，返回绑定的钱包地址
+func (s *authService) AuthenticateAPIKey(ctx context.Context, req APIKeyAuthReq) (string, error) {
+	// 1. 校验时间戳
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return "", ErrSignatureExpired
+	}
+	signedAt := time.UnixMilli(ts)
+	if time.Since(signedAt) > apiKeyTimestampWindow || time.Until(signedAt) > apiKeyTimestampWindow {
+		return "", ErrSignatureExpired
+	}
+
+	// 2. 查询密钥
+	var key model.NFTAPIKey
+	err = s.db.WithContext(ctx).Where("key_hash = ?", hashAPIKey(req.APIKey)).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", err
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return "", ErrInvalidAPIKey
+	}
+
+	// 3. 校验IP白名单与权限范围
+	if !ipAllowed(key.IPAllowlist, req.ClientIP) {
+		utils.Logger.Warn("API Key请求IP不在白名单", zap.String("key_prefix", key.KeyPrefix), zap.String("client_ip", req.ClientIP))
+		return "", fmt.Errorf("%w：IP不在白名单", ErrAPIKeyForbidden)
+	}
+	if req.Scope != "" && !slices.Contains(strings.Split(key.Scopes, ","), req.Scope) {
+		return "", fmt.Errorf("%w：缺少%s权限", ErrAPIKeyForbidden, req.Scope)
+	}
+
+	// 4. 校验HMAC签名
+	secret, err := utils.DecryptSecret(config.GlobalConfig.APIKeyEncryptionKey, key.SecretCipher)
+	if err != nil {
+		utils.Logger.Error("解密API密钥失败", zap.String("key_prefix", key.KeyPrefix), zap.Error(err))
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(APIKeySignPayload(req.Timestamp, req.Method, req.Path, req.Body)))
+	signature, err := hex.DecodeString(req.Signature)
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", ErrInvalidSignature
+	}
+
+	// 5. 频率限制（按密钥每分钟计数，签名校验通过后计数，未持有密钥者无法消耗该密钥的配额）
+	rateKey := fmt.Sprintf("nft_apikey_rate_%d_%d", key.ID, time.Now().Unix()/60)
+	count, err := utils.RedisClient.Incr(ctx, rateKey).Result()
+	if err != nil {
+		return "", err
+	}
+	if count == 1 {
+		utils.RedisClient.Expire(ctx, rateKey, time.Minute)
+	}
+	if count > int64(key.RateLimit) {
+		return "", ErrRateLimited
+	}
+
+	// 6. 防重放（时间窗口内同一签名只能使用一次）
+	ok, err := utils.RedisClient.SetNX(ctx, fmt.Sprintf("nft_apikey_sig_%s", strings.ToLower(req.Signature)), 1, 2*apiKeyTimestampWindow).Result()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrSignatureReused
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&key).UpdateColumn("last_used_at", &now).Error; err != nil {
+		utils.Logger.Warn("更新API密钥使用时间失败", zap.Uint64("id", key.ID), zap.Error(err))
+	}
+	return key.UserAddr, nil
+}
+
+// APIKeySignPayload API密钥请求待签内容：时间戳、请求方法、路径（含查询参数）、请求体以换行拼接
+// 签名为HMAC-SHA256(secret, payload)的十六进制编码
+func APIKeySignPayload(timestamp, method, path string, body []byte) string {
+	return strings.Join([]string{timestamp, strings.ToUpper(method), path, string(body)}, "\n")
+}
+
+// hashAPIKey API Key的SHA-256哈希（落库及查询使用）
+func hashAPIKey(apiKey string) string {
+	digest := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(digest[:])
+}
+
+// parseIPEntry 解析白名单条目（IP或CIDR）
+func parseIPEntry(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		return netip.ParsePrefix(entry)
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ipAllowed 校验客户端IP是否在白名单内（白名单为空不限）
+func ipAllowed(allowlist, clientIP string) bool {
+	if allowlist == "" {
+		return true
+	}
+	ip, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	for _, entry := range strings.Split(allowlist, ",") {
+		prefix, err := parseIPEntry(entry)
+		if err == nil && prefix.Contains(ip.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// toAPIKeyInfo 转换为API密钥信息
+func toAPIKeyInfo(key model.NFTAPIKey) APIKeyInfo {
+	info := APIKeyInfo{
+		ID:         key.ID,
+		Name:       key.Name,
+		KeyPrefix:  key.KeyPrefix,
+		Scopes:     strings.Split(key.Scopes, ","),
+		RateLimit:  key.RateLimit,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+	if key.IPAllowlist != "" {
+		info.IPAllowlist = strings.Split(key.IPAllowlist, ",")
+	}
+	return info
+}
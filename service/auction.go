@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// -------------- 请求结构体 --------------
+// PlaceBidReq 拍卖出价请求
+type PlaceBidReq struct {
+	OrderNo    string `json:"order_no"`
+	BidderAddr string `json:"bidder_addr"`
+	Amount     string `json:"amount"` // 出价金额（wei单位）
+}
+
+// GetBidsReq 查询出价请求
+type GetBidsReq struct {
+	OrderNo    string `json:"order_no"`    // 拍卖订单编号
+	BidderAddr string `json:"bidder_addr"` // 出价人地址
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+}
+
+// -------------- 核心方法 --------------
+// PlaceBid 英式拍卖出价
+func (s *tradeService) PlaceBid(ctx context.Context, req PlaceBidReq) (string, error) {
+	amount, ok := parseWei(req.Amount)
+	if !ok {
+		return "", errors.New("出价金额格式错误")
+	}
+
+	// 1. 分布式锁：同一拍卖串行出价（锁10秒）
+	lockKey := fmt.Sprintf("nft_order_lock_%s", req.OrderNo)
+	mutex, err := utils.GetRedisLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		utils.Logger.Error("获取分布式锁失败", zap.String("lockKey", lockKey), zap.Error(err))
+		return "", errors.New("当前拍卖出价繁忙，请稍后再试")
+	}
+	defer utils.ReleaseRedisLock(mutex)
+
+	// 2. 校验拍卖状态：英式拍卖、待成交、已开始且未结束
+	now := time.Now()
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Where("order_no = ? AND order_type = 1 AND status = ? AND start_time <= ? AND end_time > ?", req.OrderNo, model.NFTOrderStatusPending, now, now).First(&order).Error; err != nil {
+		utils.Logger.Error("校验拍卖失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return "", errors.New("拍卖不存在或已结束")
+	}
+	if strings.EqualFold(order.SellerAddr, req.BidderAddr) {
+		return "", errors.New("不能对自己的拍卖出价")
+	}
+
+	// 3. 校验出价金额：首次出价不低于起拍价，后续出价不低于当前最高价+最小加价幅度
+	var leading model.NFTBid
+	err = s.db.WithContext(ctx).Where("order_no = ? AND status = ?", req.OrderNo, model.BidStatusLeading).First(&leading).Error
+	hasLeading := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if hasLeading {
+		if strings.EqualFold(leading.BidderAddr, req.BidderAddr) {
+			return "", errors.New("当前已是最高出价")
+		}
+		leadingAmount, _ := parseWei(leading.Amount)
+		minIncrement, _ := parseWei(order.MinIncrement)
+		minAmount := new(big.Int).Add(leadingAmount, minIncrement)
+		if amount.Cmp(minAmount) < 0 || amount.Cmp(leadingAmount) <= 0 {
+			return "", fmt.Errorf("出价过低，最低出价为%s", minAmount.String())
+		}
+	} else {
+		startPrice, _ := parseWei(order.Price)
+		if amount.Cmp(startPrice) < 0 {
+			return "", fmt.Errorf("出价过低，起拍价为%s", startPrice.String())
+		}
+	}
+
+	// 4. 防狙击：结束前窗口期内出价则延长结束时间
+	endTime := order.EndTime
+	if order.EndTime.Sub(now) < config.GlobalConfig.AuctionExtendWindow {
+		endTime = now.Add(config.GlobalConfig.AuctionExtendExtension)
+	}
+
+	// 5. 事务：释放原领先出价 + 创建新出价 + 更新拍卖结束时间
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if hasLeading {
+		if err := tx.Model(&leading).Update("status", model.BidStatusOutbid).Error; err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	bid := model.NFTBid{
+		BidNo:      uuid.NewString(),
+		OrderNo:    req.OrderNo,
+		NFTAssetID: order.NFTAssetID,
+		BidderAddr: req.BidderAddr,
+		Amount:     amount.String(),
+		Status:     model.BidStatusLeading,
+		BidTime:    now,
+	}
+	if err := tx.Create(&bid).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("创建出价失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return "", err
+	}
+
+	// 条件更新：防止与结算任务并发
+	result := tx.Model(&model.NFTOrder{}).Where("order_no = ? AND status = ? AND end_time > ?", req.OrderNo, model.NFTOrderStatusPending, now).Update("end_time", endTime)
+	if result.Error != nil {
+		tx.Rollback()
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return "", errors.New("拍卖不存在或已结束")
+	}
+
+	tx.Commit()
+
+	// 6. 通知被超越的出价人：出价不冻结资金（中标后在链上交割时付款），被超越的出价置为已释放即可，无需退款
+	if hasLeading {
+		if err := utils.PublishOrderEvent(ctx, "auction.outbid", req.OrderNo, map[string]interface{}{
+			"bid_no":      leading.BidNo,
+			"bidder_addr": leading.BidderAddr,
+			"amount":      leading.Amount,
+		}); err != nil {
+			utils.Logger.Error("发布出价被超越事件失败", zap.String("bid_no", leading.BidNo), zap.Error(err))
+		}
+	}
+
+	utils.Logger.Info("拍卖出价成功", zap.String("order_no", req.OrderNo), zap.String("bid_no", bid.BidNo), zap.String("amount", bid.Amount))
+	return bid.BidNo, nil
+}
+
+// GetBids 查询出价列表（按拍卖或出价人）
+func (s *tradeService) GetBids(ctx context.Context, req GetBidsReq) ([]model.NFTBid, int64, error) {
+	var bids []model.NFTBid
+	var total int64
+
+	// 构建查询条件
+	query := s.db.WithContext(ctx).Model(&model.NFTBid{})
+	if req.OrderNo != "" {
+		query = query.Where("order_no = ?", req.OrderNo)
+	}
+	if req.BidderAddr != "" {
+		query = query.Where("bidder_addr = ?", req.BidderAddr)
+	}
+
+	// 统计总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Offset(offset).Limit(req.PageSize).Order("bid_time DESC").Find(&bids).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return bids, total, nil
+}
+
+// settleAuction 拍卖结算：达到保留价则以最高出价成交，否则流拍并解锁资产
+func settleAuction(ctx context.Context, db *gorm.DB, order model.NFTOrder) error {
+	// 与出价、取消共用拍卖锁：持锁期间不会有新出价提交（锁被占用时等待下一轮结算）
+	lockKey := fmt.Sprintf("nft_order_lock_%s", order.OrderNo)
+	mutex, err := utils.TryRedisLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("拍卖正在处理出价或取消，等待下一轮结算：%w", err)
+	}
+	defer utils.ReleaseRedisLock(mutex)
+
+	// 持锁后重新读取订单与领先出价（出价可能已延长结束时间，或拍卖已被取消）
+	if err := db.WithContext(ctx).Where("order_no = ? AND status = ? AND end_time <= ?", order.OrderNo, model.NFTOrderStatusPending, time.Now()).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	var leading model.NFTBid
+	err = db.WithContext(ctx).Where("order_no = ? AND status = ?", order.OrderNo, model.BidStatusLeading).First(&leading).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	hasLeading := err == nil
+
+	// 判断是否成交
+	sold := false
+	if hasLeading {
+		sold = true
+		if order.ReservePrice != "" {
+			leadingAmount, _ := parseWei(leading.Amount)
+			reservePrice, _ := parseWei(order.ReservePrice)
+			sold = leadingAmount.Cmp(reservePrice) >= 0
+		}
+	}
+
+	tx := db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if sold {
+		// 成交：订单进入处理中，由交易执行消息完成链上交割
+		if err := transitionOrder(tx, orderTransition{
+			OrderNo:  order.OrderNo,
+			From:     model.NFTOrderStatusPending,
+			To:       model.NFTOrderStatusProcessing,
+			Operator: operatorAuctionSettle,
+			Reason:   "拍卖成交，出价编号：" + leading.BidNo,
+			Updates: map[string]interface{}{
+				"buyer_addr": leading.BidderAddr,
+				"deal_price": leading.Amount,
+			},
+			Where: "end_time <= ?",
+			Args:  []interface{}{time.Now()},
+		}); err != nil {
+			tx.Rollback()
+			if errors.Is(err, ErrOrderStateChanged) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Model(&leading).Update("status", model.BidStatusWon).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		tx.Commit()
+
+		if err := utils.PublishTradeMsg(ctx, order.OrderNo); err != nil {
+			// 回滚订单状态，等待下一轮结算重试
+			transitionOrderTx(ctx, db, orderTransition{
+				OrderNo:  order.OrderNo,
+				From:     model.NFTOrderStatusProcessing,
+				To:       model.NFTOrderStatusPending,
+				Operator: operatorAuctionSettle,
+				Reason:   "发布交易消息失败，等待重新结算",
+				Updates: map[string]interface{}{
+					"buyer_addr": "",
+					"deal_price": "",
+				},
+			})
+			db.WithContext(ctx).Model(&leading).Update("status", model.BidStatusLeading)
+			return err
+		}
+
+		utils.Logger.Info("拍卖成交", zap.String("order_no", order.OrderNo), zap.String("bid_no", leading.BidNo), zap.String("amount", leading.Amount))
+		return nil
+	}
+
+	// 流拍：订单置为已过期 + 解锁资产 + 释放领先出价
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  order.OrderNo,
+		From:     model.NFTOrderStatusPending,
+		To:       model.NFTOrderStatusExpired,
+		Operator: operatorAuctionSettle,
+		Reason:   "拍卖流拍",
+		Where:    "end_time <= ?",
+		Args:     []interface{}{time.Now()},
+	}); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrOrderStateChanged) {
+			return nil
+		}
+		return err
+	}
+	unlockTime := time.Now()
+	if err := tx.Model(&model.NFTAssetLock{}).Where("order_no = ? AND unlock_time IS NULL", order.OrderNo).Update("unlock_time", &unlockTime).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if hasLeading {
+		if err := tx.Model(&leading).Update("status", model.BidStatusUnsold).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	tx.Commit()
+
+	eventData := map[string]interface{}{
+		"nft_asset_id": order.NFTAssetID,
+		"seller_addr":  order.SellerAddr,
+	}
+	if hasLeading {
+		eventData["bid_no"] = leading.BidNo
+		eventData["bidder_addr"] = leading.BidderAddr
+		eventData["amount"] = leading.Amount
+	}
+	if err := utils.PublishOrderEvent(ctx, "auction.unsold", order.OrderNo, eventData); err != nil {
+		utils.Logger.Error("发布拍卖流拍事件失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+	}
+
+	utils.Logger.Info("拍卖流拍", zap.String("order_no", order.OrderNo))
+	return nil
+}
+
+// validateAuctionParams 校验英式拍卖参数
+func validateAuctionParams(req CreateSellOrderReq) error {
+	startPrice, _ := parseWei(req.Price)
+	if req.ReservePrice != "" {
+		reservePrice, ok := parseWei(req.ReservePrice)
+		if !ok {
+			return errors.New("保留价格式错误")
+		}
+		if reservePrice.Cmp(startPrice) < 0 {
+			return errors.New("保留价不能低于起拍价")
+		}
+	}
+	if req.MinIncrement != "" {
+		if _, ok := parseWei(req.MinIncrement); !ok {
+			return errors.New("最小加价幅度格式错误")
+		}
+	}
+	return nil
+}
+
+// parseWei 解析wei金额字符串（非负整数），空字符串视为0
+func parseWei(s string) (*big.Int, bool) {
+	if s == "" {
+		return new(big.Int), true
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	goredis "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidSIWEMessage 登录消息格式或字段校验失败
+	ErrInvalidSIWEMessage = errors.New("登录消息校验失败")
+	// ErrInvalidNonce 登录nonce不存在、已过期或已被使用
+	ErrInvalidNonce = errors.New("登录nonce无效或已被使用")
+	// ErrUnauthorized 未登录或会话已过期
+	ErrUnauthorized = errors.New("未登录或登录已过期")
+)
+
+// AuthService 登录服务接口（Sign-In With Ethereum会话及交易机器人API密钥）
+type AuthService interface {
+	GetNonce(ctx context.Context) (*SIWENonceResp, error)
+	Verify(ctx context.Context, req SIWEVerifyReq) (*SIWEVerifyResp, error)
+	Authenticate(ctx context.Context, token string) (string, error)
+	Logout(ctx context.Context, token string) error
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyReq) (*CreateAPIKeyResp, error)
+	ListAPIKeys(ctx context.Context, userAddr string) ([]APIKeyInfo, error)
+	DeleteAPIKey(ctx context.Context, userAddr string, id uint64) error
+	AuthenticateAPIKey(ctx context.Context, req APIKeyAuthReq) (string, error)
+}
+
+// authService 登录服务实现（nonce与会话保存在Redis，API密钥保存在MySQL）
+type authService struct {
+	db *gorm.DB
+}
+
+// NewAuthService 创建登录服务
+func NewAuthService(db *gorm.DB) AuthService {
+	return &authService{
+		db: db,
+	}
+}
+
+// -------------- 请求结构体 --------------
+// SIWENonceResp 登录nonce
+type SIWENonceResp struct {
+	Nonce     string    `json:"nonce"`
+	Domain    string    `json:"domain"`     // 签名消息须声明的域名
+	ExpiresAt time.Time `json:"expires_at"` // nonce过期时间
+}
+
+// SIWEVerifyReq 登录校验请求
+type SIWEVerifyReq struct {
+	Message   string `json:"message" binding:"required"`   // EIP-4361消息原文
+	Signature string `json:"signature" binding:"required"` // 钱包对消息的personal_sign签名
+}
+
+// SIWEVerifyResp 登录结果
+type SIWEVerifyResp struct {
+	Token     string    `json:"token"`   // 会话令牌，请求时通过Authorization: Bearer携带
+	Address   string    `json:"address"` // 登录钱包地址
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// -------------- 核心方法 --------------
+// GetNonce 生成一次性登录nonce
+func (s *authService) GetNonce(ctx context.Context) (*SIWENonceResp, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	ttl := config.GlobalConfig.SIWENonceTTL
+	if err := utils.RedisClient.Set(ctx, siweNonceKey(nonce), 1, ttl).Err(); err != nil {
+		utils.Logger.Error("保存登录nonce失败", zap.Error(err))
+		return nil, err
+	}
+	return &SIWENonceResp{
+		Nonce:     nonce,
+		Domain:    config.GlobalConfig.SIWEDomain,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Verify 校验SIWE消息及签名，消耗nonce并签发绑定签名地址的会话
+func (s *authService) Verify(ctx context.Context, req SIWEVerifyReq) (*SIWEVerifyResp, error) {
+	// 1. 解析并校验消息字段
+	msg, err := utils.ParseSIWEMessage(req.Message)
+	if err != nil {
+		return nil, fmt.Errorf("%w：%s", ErrInvalidSIWEMessage, err.Error())
+	}
+	if msg.Domain != config.GlobalConfig.SIWEDomain {
+		return nil, fmt.Errorf("%w：域名不匹配", ErrInvalidSIWEMessage)
+	}
+	if _, ok := config.GlobalConfig.ChainRPCUrl[msg.ChainID]; !ok {
+		return nil, fmt.Errorf("%w：不支持的链ID", ErrInvalidSIWEMessage)
+	}
+	if !msg.ValidAt(time.Now()) {
+		return nil, fmt.Errorf("%w：消息不在有效期内", ErrInvalidSIWEMessage)
+	}
+
+	// 2. 校验签名者与消息声明的地址一致（合约钱包在消息声明的链上校验）
+	if err := verifyMessageSignature(ctx, msg.ChainID, msg.Address, req.Message, req.Signature); err != nil {
+		utils.Logger.Warn("登录签名校验失败", zap.String("address", msg.Address), zap.Error(err))
+		return nil, err
+	}
+	signer := common.HexToAddress(msg.Address)
+
+	// 3. 消耗nonce（删除成功才视为有效，防止重放）
+	deleted, err := utils.RedisClient.Del(ctx, siweNonceKey(msg.Nonce)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, ErrInvalidNonce
+	}
+
+	// 4. 签发会话（会话有效期不超过消息过期时间）
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(config.GlobalConfig.SIWESessionTTL)
+	if msg.ExpirationTime != nil && msg.ExpirationTime.Before(expiresAt) {
+		expiresAt = *msg.ExpirationTime
+	}
+	if err := utils.RedisClient.Set(ctx, siweSessionKey(token), signer.Hex(), time.Until(expiresAt)).Err(); err != nil {
+		utils.Logger.Error("保存登录会话失败", zap.Error(err))
+		return nil, err
+	}
+
+	utils.Logger.Info("钱包登录成功", zap.String("address", signer.Hex()))
+	return &SIWEVerifyResp{
+		Token:     token,
+		Address:   signer.Hex(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Authenticate 校验会话令牌，返回登录钱包地址
+func (s *authService) Authenticate(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrUnauthorized
+	}
+	addr, err := utils.RedisClient.Get(ctx, siweSessionKey(token)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", ErrUnauthorized
+	}
+	if err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// Logout 注销会话
+func (s *authService) Logout(ctx context.Context, token string) error {
+	return utils.RedisClient.Del(ctx, siweSessionKey(token)).Err()
+}
+
+// siweNonceKey 登录nonce缓存键
+func siweNonceKey(nonce string) string {
+	return fmt.Sprintf("nft_siwe_nonce_%s", nonce)
+}
+
+// siweSessionKey 登录会话缓存键
+func siweSessionKey(token string) string {
+	return fmt.Sprintf("nft_siwe_session_%s", token)
+}
+
+// randomHex 生成n字节随机数的十六进制字符串
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/go-redsync/redsync/v4"
+	"go.uber.org/zap"
+)
+
+// maxBatchSize 批量接口单次最多处理条数
+const maxBatchSize = 50
+
+// -------------- 请求结构体 --------------
+// BatchCreateSellOrderReq 批量创建出售订单请求
+type BatchCreateSellOrderReq struct {
+	SellerAddr string               `json:"seller_addr"`
+	Items      []CreateSellOrderReq `json:"items"` // 每项的seller_addr以外层为准
+}
+
+// BatchCancelSellOrderReq 批量取消出售订单请求
+type BatchCancelSellOrderReq struct {
+	SellerAddr string   `json:"seller_addr"`
+	OrderNos   []string `json:"order_nos"`
+}
+
+// BatchItemResult 批量操作单项结果
+type BatchItemResult struct {
+	NFTAssetID uint64 `json:"nft_asset_id,omitempty"`
+	OrderNo    string `json:"order_no,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// -------------- 核心方法 --------------
+// BatchCreateSellOrder 批量创建出售订单
+// 每项独立事务，单项失败不影响其他项；资产锁一次性并发抢占（不重试），抢占失败的项直接返回失败
+func (s *tradeService) BatchCreateSellOrder(ctx context.Context, req BatchCreateSellOrderReq) ([]BatchItemResult, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("批量挂单列表为空")
+	}
+	if len(req.Items) > maxBatchSize {
+		return nil, fmt.Errorf("单次最多挂单%d个", maxBatchSize)
+	}
+
+	results := make([]BatchItemResult, len(req.Items))
+	assetIDs := make([]uint64, 0, len(req.Items))
+	seen := make(map[uint64]bool, len(req.Items))
+	for i := range req.Items {
+		req.Items[i].SellerAddr = req.SellerAddr
+		results[i].NFTAssetID = req.Items[i].NFTAssetID
+		if seen[req.Items[i].NFTAssetID] {
+			results[i].Error = "NFT资产重复"
+			continue
+		}
+		seen[req.Items[i].NFTAssetID] = true
+		assetIDs = append(assetIDs, req.Items[i].NFTAssetID)
+	}
+
+	// 1. 批量查询卖家持有的ERC-721资产（ERC-1155持有量不记录在owner_addr，须按数量单独挂单）
+	var assets []model.NFTAsset
+	if err := s.db.WithContext(ctx).Where("id IN ? AND owner_addr = ? AND token_standard = ? AND status = 0", assetIDs, req.SellerAddr, model.TokenStandardERC721).Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	assetMap := make(map[uint64]model.NFTAsset, len(assets))
+	for _, asset := range assets {
+		assetMap[asset.ID] = asset
+	}
+
+	// 2. 逐项校验挂单参数与链上所有权、操作员授权（与单个挂单共用校验，持锁前完成RPC查询）
+	lockTypes := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		asset, ok := assetMap[item.NFTAssetID]
+		if !ok || results[i].Error != "" {
+			continue
+		}
+		lockType, err := validateERC721Listing(ctx, item, asset)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		lockTypes[i] = lockType
+	}
+
+	// 3. 并发抢占资产分布式锁（与单个挂单共用锁键）
+	mutexes := tryLockAssets(ctx, assetIDs)
+	defer func() {
+		for _, mutex := range mutexes {
+			utils.ReleaseRedisLock(mutex)
+		}
+	}()
+
+	// 4. 持锁后批量查询已锁定的资产
+	var lockRecords []model.NFTAssetLock
+	if err := s.db.WithContext(ctx).Where("nft_asset_id IN ? AND unlock_time IS NULL", assetIDs).Find(&lockRecords).Error; err != nil {
+		return nil, err
+	}
+	locked := make(map[uint64]bool, len(lockRecords))
+	for _, lockRecord := range lockRecords {
+		locked[lockRecord.NFTAssetID] = true
+	}
+
+	// 5. 逐项创建订单
+	for i, item := range req.Items {
+		if results[i].Error != "" {
+			continue
+		}
+		asset, ok := assetMap[item.NFTAssetID]
+		if !ok {
+			results[i].Error = "NFT资产不存在或不属于当前用户，或资产状态异常（批量挂单仅支持ERC-721资产）"
+			continue
+		}
+		if _, ok := mutexes[item.NFTAssetID]; !ok {
+			results[i].Error = "当前资产正在处理中，请稍后再试"
+			continue
+		}
+		if locked[item.NFTAssetID] {
+			results[i].Error = "NFT资产已被锁定，无法挂单"
+			continue
+		}
+
+		orderNo, err := s.createSellOrder(ctx, item, asset, lockTypes[i])
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].OrderNo = orderNo
+		results[i].Success = true
+	}
+
+	return results, nil
+}
+
+// BatchCancelSellOrder 批量取消出售订单，单项失败不影响其他项
+func (s *tradeService) BatchCancelSellOrder(ctx context.Context, req BatchCancelSellOrderReq) ([]BatchItemResult, error) {
+	if len(req.OrderNos) == 0 {
+		return nil, errors.New("批量取消列表为空")
+	}
+	if len(req.OrderNos) > maxBatchSize {
+		return nil, fmt.Errorf("单次最多取消%d个订单", maxBatchSize)
+	}
+
+	results := make([]BatchItemResult, len(req.OrderNos))
+	for i, orderNo := range req.OrderNos {
+		results[i].OrderNo = orderNo
+		if err := s.CancelSellOrder(ctx, CancelSellOrderReq{OrderNo: orderNo, SellerAddr: req.SellerAddr}); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Success = true
+	}
+
+	return results, nil
+}
+
+// tryLockAssets 并发抢占多个资产的分布式锁，返回抢占成功的锁（资产ID -> 锁）
+func tryLockAssets(ctx context.Context, assetIDs []uint64) map[uint64]*redsync.Mutex {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	mutexes := make(map[uint64]*redsync.Mutex, len(assetIDs))
+	for _, assetID := range assetIDs {
+		wg.Add(1)
+		go func(assetID uint64) {
+			defer wg.Done()
+			lockKey := fmt.Sprintf("nft_lock_%d", assetID)
+			mutex, err := utils.TryRedisLock(ctx, lockKey, 10*time.Second)
+			if err != nil {
+				utils.Logger.Warn("抢占资产锁失败", zap.String("lockKey", lockKey), zap.Error(err))
+				return
+			}
+			mu.Lock()
+			mutexes[assetID] = mutex
+			mu.Unlock()
+		}(assetID)
+	}
+	wg.Wait()
+	return mutexes
+}
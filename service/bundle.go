@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CreateBundleOrderReq 创建组合出售订单请求（多个NFT一口价出售）
+type CreateBundleOrderReq struct {
+	SellerAddr  string     `json:"seller_addr"`
+	NFTAssetIDs []uint64   `json:"nft_asset_ids"`
+	Price       string     `json:"price"` // 组合总价（wei单位）
+	ChainID     int        `json:"chain_id"`
+	EndTime     *time.Time `json:"end_time"` // 可选，默认7天
+}
+
+// CreateBundleOrder 创建组合出售订单（全部NFT挂单成功或全部失败）
+func (s *tradeService) CreateBundleOrder(ctx context.Context, req CreateBundleOrderReq) (string, error) {
+	// 1. 校验参数
+	if len(req.NFTAssetIDs) < 2 {
+		return "", errors.New("组合订单至少包含2个NFT")
+	}
+	if len(req.NFTAssetIDs) > maxBatchSize {
+		return "", fmt.Errorf("组合订单最多包含%d个NFT", maxBatchSize)
+	}
+	seen := make(map[uint64]bool, len(req.NFTAssetIDs))
+	for _, assetID := range req.NFTAssetIDs {
+		if seen[assetID] {
+			return "", errors.New("组合订单包含重复的NFT")
+		}
+		seen[assetID] = true
+	}
+	if price, ok := parseWei(req.Price); !ok || price.Sign() <= 0 {
+		return "", errors.New("价格格式错误")
+	}
+
+	// 2. 校验NFT资产均为卖家持有的ERC-721资产且在同一条链上（批量转账合约仅支持ERC-721）
+	var assets []model.NFTAsset
+	if err := s.db.WithContext(ctx).Where("id IN ? AND owner_addr = ? AND token_standard = ? AND status = 0", req.NFTAssetIDs, req.SellerAddr, model.TokenStandardERC721).Find(&assets).Error; err != nil {
+		return "", err
+	}
+	if len(assets) != len(req.NFTAssetIDs) {
+		return "", errors.New("部分NFT资产不存在或不属于当前用户，或资产状态异常（组合订单仅支持ERC-721资产）")
+	}
+	contractAddr := assets[0].ContractAddr
+	for _, asset := range assets {
+		if asset.ChainID != req.ChainID {
+			return "", errors.New("组合订单内的NFT必须属于同一条链")
+		}
+		if !strings.EqualFold(asset.ContractAddr, contractAddr) {
+			contractAddr = "" // 跨合约组合
+		}
+	}
+
+	// 3. 校验链上所有权及批量转账合约授权
+	if err := checkListingOnChain(ctx, req.ChainID, req.SellerAddr, listingOperator(req.ChainID, true), assets); err != nil {
+		return "", err
+	}
+
+	// 4. 分布式锁：抢占全部资产锁，任一失败则整体失败
+	mutexes := tryLockAssets(ctx, req.NFTAssetIDs)
+	defer func() {
+		for _, mutex := range mutexes {
+			utils.ReleaseRedisLock(mutex)
+		}
+	}()
+	if len(mutexes) != len(req.NFTAssetIDs) {
+		return "", errors.New("部分资产正在处理中，请稍后再试")
+	}
+
+	// 5. 校验资产均未被锁定
+	var lockCount int64
+	if err := s.db.WithContext(ctx).Model(&model.NFTAssetLock{}).Where("nft_asset_id IN ? AND unlock_time IS NULL", req.NFTAssetIDs).Count(&lockCount).Error; err != nil {
+		return "", err
+	}
+	if lockCount > 0 {
+		return "", errors.New("部分NFT资产已被锁定，无法挂单")
+	}
+
+	// 6. 构建订单
+	orderNo := uuid.NewString()
+	endTime := time.Now().Add(7 * 24 * time.Hour) // 默认7天
+	if req.EndTime != nil {
+		endTime = *req.EndTime
+	}
+	order := model.NFTOrder{
+		OrderNo:      orderNo,
+		ContractAddr: contractAddr,
+		IsBundle:     true,
+		SellerAddr:   req.SellerAddr,
+		Price:        req.Price,
+		OrderType:    0, // 组合订单仅支持一口价
+		Status:       model.NFTOrderStatusPending,
+		ChainID:      req.ChainID,
+		StartTime:    time.Now(),
+		EndTime:      endTime,
+	}
+
+	// 7. 事务：创建订单 + 创建明细 + 锁定全部资产
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("创建组合订单失败", zap.Error(err))
+		return "", err
+	}
+
+	items := make([]model.NFTOrderItem, 0, len(assets))
+	for _, asset := range assets {
+		items = append(items, model.NFTOrderItem{
+			OrderNo:      orderNo,
+			NFTAssetID:   asset.ID,
+			TokenID:      asset.TokenID,
+			ContractAddr: asset.ContractAddr,
+		})
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("创建组合订单明细失败", zap.Error(err))
+		return "", err
+	}
+
+	for _, asset := range assets {
+		if err := lockAsset(tx, asset.ID, orderNo, 0); err != nil {
+			tx.Rollback()
+			utils.Logger.Error("锁定资产失败", zap.Uint64("nft_asset_id", asset.ID), zap.Error(err))
+			return "", err
+		}
+	}
+
+	if err := recordStatusChange(tx, orderNo, model.NFTOrderStatusNew, model.NFTOrderStatusPending, req.SellerAddr, "卖家组合挂单"); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+
+	return orderNo, nil
+}
+
+// loadOrderAssets 查询订单待交割的NFT资产（组合订单为全部明细）
+func (s *tradeService) loadOrderAssets(ctx context.Context, order model.NFTOrder) ([]model.NFTAsset, error) {
+	var assets []model.NFTAsset
+	if !order.IsBundle {
+		if err := s.db.WithContext(ctx).Where("id = ?", order.NFTAssetID).Find(&assets).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.db.WithContext(ctx).Where("id IN (?)", s.db.Model(&model.NFTOrderItem{}).Select("nft_asset_id").Where("order_no = ?", order.OrderNo)).Find(&assets).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(assets) == 0 {
+		return nil, errors.New("订单关联的NFT资产不存在")
+	}
+	return assets, nil
+}
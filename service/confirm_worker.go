@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/contract"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// settlementSendTimeout 交割交易签名与广播的超时时间
+	settlementSendTimeout = 30 * time.Second
+	// operatorSendLockTTL 操作员发送锁有效期（大于签名与广播超时，覆盖交易落库耗时）
+	operatorSendLockTTL = 2 * settlementSendTimeout
+)
+
+// txTrackers 链ID -> 交易广播与状态查询器（首次使用时按配置的RPC地址连接）
+var (
+	txTrackerMu sync.Mutex
+	txTrackers  = make(map[int]*contract.TxTracker)
+)
+
+// SetTxTracker 指定链使用的交易广播与状态查询器（如go-ethereum模拟链后端）
+func SetTxTracker(chainID int, tracker *contract.TxTracker) {
+	txTrackerMu.Lock()
+	defer txTrackerMu.Unlock()
+	txTrackers[chainID] = tracker
+}
+
+// getTxTracker 获取链对应的交易广播与状态查询器
+func getTxTracker(chainID int) (*contract.TxTracker, error) {
+	txTrackerMu.Lock()
+	defer txTrackerMu.Unlock()
+	if tracker, ok := txTrackers[chainID]; ok {
+		return tracker, nil
+	}
+	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[chainID]
+	if !ok {
+		return nil, errors.New("链配置不存在")
+	}
+	tracker, err := contract.DialTxTracker(rpcUrl)
+	if err != nil {
+		return nil, err
+	}
+	txTrackers[chainID] = tracker
+	return tracker, nil
+}
+
+// ConfirmWorker 交割交易确认任务
+// 定期检查确认中的交易记录：交易达到链的确认区块数后按执行结果完成交割或置为失败；
+// 交易连续多次未查询到时原样重新广播持久化的原始交易（同一nonce，不会重复转账），
+// 仅当该nonce已被确认区块内的其他交易占用（本交易不可能再上链）时置为失败并释放资产
+type ConfirmWorker struct {
+	svc              *tradeService
+	interval         time.Duration
+	batchSize        int
+	maxResubmits     int
+	missingThreshold int
+}
+
+// NewConfirmWorker 创建交割交易确认任务
+func NewConfirmWorker(db *gorm.DB, interval time.Duration, batchSize, maxResubmits, missingThreshold int) *ConfirmWorker {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if maxResubmits < 0 {
+		maxResubmits = 0
+	}
+	if missingThreshold <= 0 {
+		missingThreshold = 3
+	}
+	return &ConfirmWorker{
+		svc:              &tradeService{db: db},
+		interval:         interval,
+		batchSize:        batchSize,
+		maxResubmits:     maxResubmits,
+		missingThreshold: missingThreshold,
+	}
+}
+
+// Start 启动确认任务（阻塞，直到ctx取消）
+func (w *ConfirmWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.Logger.Info("交割交易确认任务已停止")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce 执行一轮确认检查
+func (w *ConfirmWorker) runOnce(ctx context.Context) {
+	// 分布式锁：多实例部署时仅一个实例执行，避免重复处理；
+	// 锁有效期为扫描间隔的4倍，本轮处理在锁到期前一个间隔超时退出，锁不会在处理中途过期
+	lockTTL := 4 * w.interval
+	mutex, err := utils.TryRedisLock(ctx, "nft_trade_confirm_worker", lockTTL)
+	if err != nil {
+		return
+	}
+	defer utils.ReleaseRedisLock(mutex)
+	ctx, cancel := context.WithTimeout(ctx, lockTTL-w.interval)
+	defer cancel()
+
+	var lastID uint64
+	for {
+		// 分批查询确认中的交易记录
+		var records []model.NFTTradeRecord
+		if err := w.svc.db.WithContext(ctx).
+			Where("confirm_status = ? AND id > ?", model.TradeConfirmStatusConfirming, lastID).
+			Order("id ASC").
+			Limit(w.batchSize).
+			Find(&records).Error; err != nil {
+			utils.Logger.Error("查询确认中交易记录失败", zap.Error(err))
+			return
+		}
+
+		for _, record := range records {
+			if ctx.Err() != nil {
+				return
+			}
+			lastID = record.ID
+			if err := w.checkTrade(ctx, record); err != nil {
+				utils.Logger.Error("检查交割交易确认状态失败", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash), zap.Error(err))
+			}
+		}
+
+		if len(records) < w.batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// checkTrade 检查单笔交割交易
+func (w *ConfirmWorker) checkTrade(ctx context.Context, record model.NFTTradeRecord) error {
+	tracker, err := getTxTracker(record.ChainID)
+	if err != nil {
+		return err
+	}
+
+	// 1. 查询交易在当前规范链上的状态
+	receipt, pending, err := tracker.TransactionStatus(ctx, common.HexToHash(record.TxHash))
+	if err != nil {
+		return err
+	}
+	if receipt == nil && !pending {
+		// 交易未打包且不在交易池中（广播失败、被链重组移除或节点数据延迟）
+		return w.handleMissing(ctx, tracker, record)
+	}
+
+	// 2. 记录交易所在区块（被链重组打包进其他区块时更新，重新计算确认数），重置连续未查询到次数
+	updates := map[string]interface{}{}
+	if record.MissingCount > 0 {
+		updates["missing_count"] = 0
+	}
+	if receipt != nil && receipt.BlockHash.Hex() != record.BlockHash {
+		if record.BlockHash != "" {
+			utils.Logger.Warn("交割交易所在区块发生链重组", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash),
+				zap.Uint64("old_block", record.BlockNumber), zap.Uint64("new_block", receipt.BlockNumber.Uint64()))
+		}
+		updates["block_number"] = receipt.BlockNumber.Uint64()
+		updates["block_hash"] = receipt.BlockHash.Hex()
+		record.BlockNumber = receipt.BlockNumber.Uint64()
+		record.BlockHash = receipt.BlockHash.Hex()
+	}
+	if len(updates) > 0 {
+		if err := w.svc.db.WithContext(ctx).Model(&model.NFTTradeRecord{}).Where("id = ? AND confirm_status = ?", record.ID, model.TradeConfirmStatusConfirming).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if receipt == nil {
+		// 交易在交易池中等待打包
+		return nil
+	}
+
+	// 3. 未达到确认区块数时等待下一轮（执行失败的交易同样可能被链重组后重新执行）
+	head, err := tracker.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	depth := max(config.GlobalConfig.ConfirmationDepth[record.ChainID], 1)
+	if head < record.BlockNumber || head-record.BlockNumber+1 < depth {
+		return nil
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return w.failTrade(ctx, record, "交割交易执行失败，交易哈希："+record.TxHash)
+	}
+
+	// 4. 完成交割
+	order, assets, err := w.loadTrade(ctx, record)
+	if err != nil {
+		return err
+	}
+	if err := w.svc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return finalizeTrade(tx, order, assets, record)
+	}); err != nil {
+		return err
+	}
+	utils.Logger.Info("交易执行成功", zap.String("order_no", record.OrderNo), zap.String("trade_no", record.TradeNo),
+		zap.String("tx_hash", record.TxHash), zap.Uint64("block_number", record.BlockNumber), zap.Uint64("confirmations", head-record.BlockNumber+1))
+	return nil
+}
+
+// handleMissing 处理未查询到的交割交易
+// 连续未查询到次数达到阈值才处理（避免单个RPC节点数据延迟误判）：
+// 交易nonce已被确认区块内的其他交易占用时本交易不可能再上链，交割失败；否则原样重新广播原始交易
+func (w *ConfirmWorker) handleMissing(ctx context.Context, tracker *contract.TxTracker, record model.NFTTradeRecord) error {
+	// 1. 连续未查询到次数未达阈值时等待下一轮
+	missingCount := record.MissingCount + 1
+	if missingCount < w.missingThreshold {
+		return w.svc.db.WithContext(ctx).Model(&model.NFTTradeRecord{}).Where("id = ?", record.ID).Update("missing_count", missingCount).Error
+	}
+
+	// 2. 解析持久化的原始交易
+	signedTx, err := contract.DecodeRawTx(record.RawTx)
+	if err != nil {
+		return fmt.Errorf("解析原始交易失败：%w", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(signedTx.ChainId()), signedTx)
+	if err != nil {
+		return fmt.Errorf("解析原始交易签名失败：%w", err)
+	}
+
+	// 3. nonce已被不可回滚的其他交易占用：本交易已不可能上链
+	confirmedNonce, err := tracker.ConfirmedNonce(ctx, sender, config.GlobalConfig.ConfirmationDepth[record.ChainID])
+	if err != nil {
+		return err
+	}
+	if confirmedNonce > signedTx.Nonce() {
+		return w.failTrade(ctx, record, fmt.Sprintf("交割交易未上链且nonce %d已被其他交易占用，交易哈希：%s", signedTx.Nonce(), record.TxHash))
+	}
+
+	// 4. 原样重新广播（同一签名、同一nonce，最多只有一笔能上链）
+	if record.ResubmitCount >= w.maxResubmits {
+		utils.Logger.Error("交割交易重新广播次数已用尽，等待人工处理", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash),
+			zap.Uint64("nonce", signedTx.Nonce()), zap.Int("resubmit_count", record.ResubmitCount))
+		return w.svc.db.WithContext(ctx).Model(&model.NFTTradeRecord{}).Where("id = ?", record.ID).Update("missing_count", missingCount).Error
+	}
+	utils.Logger.Warn("重新广播交割交易", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash), zap.Int("missing_count", missingCount))
+	if err := tracker.SendTransaction(ctx, signedTx); err != nil {
+		utils.Logger.Warn("重新广播交割交易失败", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash), zap.Error(err))
+	}
+	return w.svc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.NFTTradeRecord{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+			"missing_count":  0,
+			"resubmit_count": gorm.Expr("resubmit_count + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return recordStatusChange(tx, record.OrderNo, model.NFTOrderStatusConfirming, model.NFTOrderStatusConfirming, operatorConfirmWorker,
+			fmt.Sprintf("交割交易连续%d次未查询到，已重新广播，交易哈希：%s", missingCount, record.TxHash))
+	})
+}
+
+// failTrade 交割失败：订单置为失败并释放资产，交易记录置为失败
+func (w *ConfirmWorker) failTrade(ctx context.Context, record model.NFTTradeRecord, reason string) error {
+	utils.Logger.Error("交割交易确认失败", zap.String("trade_no", record.TradeNo), zap.String("tx_hash", record.TxHash), zap.String("reason", reason))
+	return w.svc.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transitionOrder(tx, orderTransition{
+			OrderNo:  record.OrderNo,
+			From:     model.NFTOrderStatusConfirming,
+			To:       model.NFTOrderStatusFailed,
+			Operator: operatorConfirmWorker,
+			Reason:   reason,
+		}); err != nil {
+			return err
+		}
+		if err := releaseOrderAssets(tx, record.OrderNo, operatorConfirmWorker); err != nil {
+			return err
+		}
+		return tx.Model(&model.NFTTradeRecord{}).Where("id = ?", record.ID).Update("confirm_status", model.TradeConfirmStatusFailed).Error
+	})
+}
+
+// loadTrade 查询交易记录对应的订单与资产
+func (w *ConfirmWorker) loadTrade(ctx context.Context, record model.NFTTradeRecord) (model.NFTOrder, []model.NFTAsset, error) {
+	var order model.NFTOrder
+	if err := w.svc.db.WithContext(ctx).Where("order_no = ?", record.OrderNo).First(&order).Error; err != nil {
+		return order, nil, err
+	}
+	assets, err := w.svc.loadOrderAssets(ctx, order)
+	if err != nil {
+		return order, nil, err
+	}
+	return order, assets, nil
+}
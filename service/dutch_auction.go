@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+)
+
+// DutchPriceResp 荷兰式拍卖当前价格
+type DutchPriceResp struct {
+	OrderNo      string    `json:"order_no"`
+	CurrentPrice string    `json:"current_price"` // 当前价格（wei单位）
+	StartPrice   string    `json:"start_price"`
+	EndPrice     string    `json:"end_price"`
+	DecayCurve   int       `json:"decay_curve"` // 0-线性 1-指数
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	ServerTime   time.Time `json:"server_time"` // 计算价格所用的服务器时间，供前端倒计时校准
+}
+
+// GetDutchPrice 查询荷兰式拍卖当前价格
+func (s *tradeService) GetDutchPrice(ctx context.Context, orderNo string) (*DutchPriceResp, error) {
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Where("order_no = ? AND order_type = 2", orderNo).First(&order).Error; err != nil {
+		utils.Logger.Error("查询荷兰式拍卖失败", zap.String("order_no", orderNo), zap.Error(err))
+		return nil, errors.New("拍卖不存在")
+	}
+
+	now := time.Now()
+	return &DutchPriceResp{
+		OrderNo:      order.OrderNo,
+		CurrentPrice: dutchCurrentPrice(order, now).String(),
+		StartPrice:   order.Price,
+		EndPrice:     order.EndPrice,
+		DecayCurve:   order.DecayCurve,
+		StartTime:    order.StartTime,
+		EndTime:      order.EndTime,
+		ServerTime:   now,
+	}, nil
+}
+
+// dutchCurrentPrice 计算荷兰式拍卖在指定时刻的价格
+// 线性：start - (start-end) * t
+// 指数：start * (end/start)^t
+// 其中t为[StartTime, EndTime]内已过去时间占比，结果向下取整到wei
+func dutchCurrentPrice(order model.NFTOrder, at time.Time) *big.Int {
+	startPrice, _ := parseWei(order.Price)
+	endPrice, _ := parseWei(order.EndPrice)
+
+	total := order.EndTime.Sub(order.StartTime)
+	elapsed := at.Sub(order.StartTime)
+	if total <= 0 || elapsed >= total {
+		return endPrice
+	}
+	if elapsed <= 0 {
+		return startPrice
+	}
+	progress := float64(elapsed) / float64(total)
+
+	var price *big.Int
+	switch order.DecayCurve {
+	case 1:
+		// 结束价为0时指数曲线无意义，退化为线性
+		if endPrice.Sign() == 0 {
+			price = linearPrice(startPrice, endPrice, progress)
+			break
+		}
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(endPrice), new(big.Float).SetInt(startPrice)).Float64()
+		factor := math.Pow(ratio, progress)
+		price, _ = new(big.Float).Mul(new(big.Float).SetInt(startPrice), big.NewFloat(factor)).Int(nil)
+	default:
+		price = linearPrice(startPrice, endPrice, progress)
+	}
+
+	// 浮点误差兜底，价格不低于结束价
+	if price.Cmp(endPrice) < 0 {
+		return endPrice
+	}
+	return price
+}
+
+// linearPrice 线性降价
+func linearPrice(startPrice, endPrice *big.Int, progress float64) *big.Int {
+	diff := new(big.Float).SetInt(new(big.Int).Sub(startPrice, endPrice))
+	decay, _ := new(big.Float).Mul(diff, big.NewFloat(progress)).Int(nil)
+	return new(big.Int).Sub(startPrice, decay)
+}
+
+// validateDutchParams 校验荷兰式拍卖参数
+func validateDutchParams(req CreateSellOrderReq) error {
+	startPrice, _ := parseWei(req.Price)
+	endPrice, ok := parseWei(req.EndPrice)
+	if !ok || req.EndPrice == "" {
+		return errors.New("结束价格式错误")
+	}
+	if endPrice.Cmp(startPrice) >= 0 {
+		return errors.New("结束价必须低于起始价")
+	}
+	if req.DecayCurve != 0 && req.DecayCurve != 1 {
+		return errors.New("不支持的降价曲线")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nft_trade/model"
+)
+
+func TestDutchCurrentPrice(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	tests := []struct {
+		name       string
+		endPrice   string
+		decayCurve int
+		at         time.Time
+		want       string
+	}{
+		{name: "线性：开始前为起始价", endPrice: "250", decayCurve: 0, at: start.Add(-time.Hour), want: "1000"},
+		{name: "线性：开始时为起始价", endPrice: "250", decayCurve: 0, at: start, want: "1000"},
+		{name: "线性：进行到一半", endPrice: "250", decayCurve: 0, at: start.Add(2 * time.Hour), want: "625"},
+		{name: "线性：结束后为结束价", endPrice: "250", decayCurve: 0, at: end.Add(time.Hour), want: "250"},
+		{name: "指数：开始前为起始价", endPrice: "250", decayCurve: 1, at: start.Add(-time.Hour), want: "1000"},
+		{name: "指数：进行到四分之一", endPrice: "250", decayCurve: 1, at: start.Add(time.Hour), want: "707"},
+		{name: "指数：进行到一半", endPrice: "250", decayCurve: 1, at: start.Add(2 * time.Hour), want: "500"},
+		{name: "指数：结束后为结束价", endPrice: "250", decayCurve: 1, at: end, want: "250"},
+		{name: "指数：结束价为0时退化为线性", endPrice: "0", decayCurve: 1, at: start.Add(time.Hour), want: "750"},
+		{name: "指数：结束价为0且已结束", endPrice: "0", decayCurve: 1, at: end.Add(time.Hour), want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := model.NFTOrder{
+				Price:      "1000",
+				EndPrice:   tt.endPrice,
+				DecayCurve: tt.decayCurve,
+				StartTime:  start,
+				EndTime:    end,
+			}
+			if got := dutchCurrentPrice(order, tt.at); got.String() != tt.want {
+				t.Fatalf("期望价格%s，实际：%s", tt.want, got.String())
+			}
+		})
+	}
+}
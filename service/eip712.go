@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strconv"
+
+	"nft_trade/config"
+	"nft_trade/model"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// EIP-712签名域
+const (
+	eip712DomainName    = "NFT Trade"
+	eip712DomainVersion = "1"
+)
+
+// nativeCurrency 原生代币（ETH/MATIC等）计价
+const nativeCurrency = "0x0000000000000000000000000000000000000000"
+
+// orderEIP712Type 挂单结构（字段顺序须与客户端/链上撮合合约一致）
+var orderEIP712Type = []apitypes.Type{
+	{Name: "seller", Type: "address"},
+	{Name: "collection", Type: "address"},
+	{Name: "tokenId", Type: "uint256"},
+	{Name: "price", Type: "uint256"},
+	{Name: "currency", Type: "address"},
+	{Name: "startTime", Type: "uint256"},
+	{Name: "endTime", Type: "uint256"},
+	{Name: "salt", Type: "uint256"},
+	{Name: "nonce", Type: "uint256"},
+	{Name: "counter", Type: "uint256"},
+}
+
+// TypedOrder EIP-712签名挂单
+type TypedOrder struct {
+	ChainID    int    `json:"chain_id"`
+	Seller     string `json:"seller"`
+	Collection string `json:"collection"`
+	TokenID    string `json:"token_id"`
+	Price      string `json:"price"`
+	Currency   string `json:"currency"`
+	StartTime  int64  `json:"start_time"` // Unix秒
+	EndTime    int64  `json:"end_time"`   // Unix秒
+	Salt       string `json:"salt"`
+	Nonce      uint64 `json:"nonce"`
+	Counter    uint64 `json:"counter"`
+}
+
+// TypedData 构建待签名的EIP-712结构化数据（签名域按链ID区分）
+func (o TypedOrder) TypedData() apitypes.TypedData {
+	domainType := []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+	}
+	domain := apitypes.TypedDataDomain{
+		Name:    eip712DomainName,
+		Version: eip712DomainVersion,
+		ChainId: math.NewHexOrDecimal256(int64(o.ChainID)),
+	}
+	if exchangeAddr := config.GlobalConfig.ExchangeAddr[o.ChainID]; exchangeAddr != "" {
+		domainType = append(domainType, apitypes.Type{Name: "verifyingContract", Type: "address"})
+		domain.VerifyingContract = exchangeAddr
+	}
+
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": domainType,
+			"Order":        orderEIP712Type,
+		},
+		PrimaryType: "Order",
+		Domain:      domain,
+		Message: apitypes.TypedDataMessage{
+			"seller":     o.Seller,
+			"collection": o.Collection,
+			"tokenId":    o.TokenID,
+			"price":      o.Price,
+			"currency":   o.Currency,
+			"startTime":  strconv.FormatInt(o.StartTime, 10),
+			"endTime":    strconv.FormatInt(o.EndTime, 10),
+			"salt":       o.Salt,
+			"nonce":      strconv.FormatUint(o.Nonce, 10),
+			"counter":    strconv.FormatUint(o.Counter, 10),
+		},
+	}
+}
+
+// typedOrderFromModel 由已落库的签名订单还原签名结构
+func typedOrderFromModel(order model.NFTOrder) TypedOrder {
+	return TypedOrder{
+		ChainID:    order.ChainID,
+		Seller:     order.SellerAddr,
+		Collection: order.ContractAddr,
+		TokenID:    order.TokenID,
+		Price:      order.Price,
+		Currency:   order.Currency,
+		StartTime:  order.StartTime.Unix(),
+		EndTime:    order.EndTime.Unix(),
+		Salt:       order.Salt,
+		Nonce:      order.Nonce,
+		Counter:    order.Counter,
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// createERC1155SellOrder 创建ERC-1155出售订单（按数量挂单，可部分成交，Price为单价）
+func (s *tradeService) createERC1155SellOrder(ctx context.Context, req CreateSellOrderReq, asset model.NFTAsset) (string, error) {
+	// 1. 校验挂单参数：仅支持一口价公开/私人挂单
+	if asset.ChainID != req.ChainID {
+		return "", errors.New("NFT资产不属于挂单指定的链")
+	}
+	if req.OrderType != 0 {
+		return "", errors.New("ERC-1155资产仅支持一口价挂单")
+	}
+	if req.typed != nil {
+		return "", errors.New("ERC-1155资产暂不支持签名挂单")
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 {
+		return "", errors.New("挂单数量格式错误")
+	}
+	if _, err := validateSellOrderReq(req); err != nil {
+		return "", err
+	}
+
+	// 2. 分布式锁：防止同一资产并发挂单（锁10秒）
+	lockKey := fmt.Sprintf("nft_lock_%d", asset.ID)
+	mutex, err := utils.GetRedisLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		utils.Logger.Error("获取分布式锁失败", zap.String("lockKey", lockKey), zap.Error(err))
+		return "", errors.New("当前资产正在处理中，请稍后再试")
+	}
+	defer utils.ReleaseRedisLock(mutex)
+
+	// 3. 校验链上持有量与操作员授权（已锁定数量取自持有量表）
+	var balance model.NFTAssetBalance
+	if err := s.db.WithContext(ctx).Where("nft_asset_id = ? AND owner_addr = ?", asset.ID, strings.ToLower(req.SellerAddr)).First(&balance).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	req.balance, err = checkERC1155ListingOnChain(ctx, req.ChainID, req.SellerAddr, listingOperator(req.ChainID, false), asset, balance.LockedQty, req.Quantity)
+	if err != nil {
+		return "", err
+	}
+
+	// 4. 创建订单并锁定挂单数量
+	return s.createSellOrder(ctx, req, asset, 0)
+}
+
+// lockAssetBalance 同步卖家链上持有量并锁定挂单数量（可售数量不足时失败）
+func lockAssetBalance(tx *gorm.DB, nftAssetID uint64, ownerAddr string, balance, quantity int64) error {
+	ownerAddr = strings.ToLower(ownerAddr)
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nft_asset_id"}, {Name: "owner_addr"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"balance": balance}),
+	}).Create(&model.NFTAssetBalance{
+		NFTAssetID: nftAssetID,
+		OwnerAddr:  ownerAddr,
+		Balance:    balance,
+	}).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&model.NFTAssetBalance{}).
+		Where("nft_asset_id = ? AND owner_addr = ? AND balance - locked_qty >= ?", nftAssetID, ownerAddr, quantity).
+		Update("locked_qty", gorm.Expr("locked_qty + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("可售数量不足，无法挂单")
+	}
+	return nil
+}
+
+// unlockAssetBalance 释放卖家锁定的挂单数量
+func unlockAssetBalance(tx *gorm.DB, nftAssetID uint64, ownerAddr string, quantity int64) error {
+	if quantity <= 0 {
+		return nil
+	}
+	return tx.Model(&model.NFTAssetBalance{}).
+		Where("nft_asset_id = ? AND owner_addr = ?", nftAssetID, strings.ToLower(ownerAddr)).
+		Update("locked_qty", gorm.Expr("GREATEST(locked_qty - ?, 0)", quantity)).Error
+}
+
+// matchERC1155Order 撮合ERC-1155挂单：占用购买数量并创建子订单异步交割，返回子订单编号
+func (s *tradeService) matchERC1155Order(ctx context.Context, req MatchOrderReq, order model.NFTOrder) (string, error) {
+	// 1. 校验购买数量并计算总价
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 0 {
+		return "", errors.New("购买数量格式错误")
+	}
+	if quantity > order.Quantity-order.FilledQty {
+		return "", errors.New("可购买数量不足")
+	}
+	unitPrice, ok := parseWei(order.Price)
+	if !ok {
+		return "", errors.New("订单价格格式错误")
+	}
+	totalPrice := new(big.Int).Mul(unitPrice, big.NewInt(quantity)).String()
+
+	// 2. 事务：占用挂单数量 + 创建子订单
+	childNo := uuid.NewString()
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 条件更新：挂单仍为待成交、未过期、未被卖家修改（版本号不变）且剩余数量充足，防止并发超卖
+	result := tx.Model(&model.NFTOrder{}).
+		Where("order_no = ? AND status = ? AND version = ? AND end_time > ? AND quantity - filled_qty >= ?", order.OrderNo, model.NFTOrderStatusPending, order.Version, time.Now(), quantity).
+		Update("filled_qty", gorm.Expr("filled_qty + ?", quantity))
+	if result.Error != nil {
+		tx.Rollback()
+		utils.Logger.Error("占用挂单数量失败", zap.String("order_no", order.OrderNo), zap.Error(result.Error))
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return "", errors.New("订单可购买数量不足或已变更，请刷新后重试")
+	}
+
+	// 全部数量已占用：挂单转为处理中，不再接受购买
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  order.OrderNo,
+		From:     model.NFTOrderStatusPending,
+		To:       model.NFTOrderStatusProcessing,
+		Operator: req.BuyerAddr,
+		Reason:   "挂单数量已全部售出，等待交割",
+		Where:    "filled_qty >= quantity",
+	}); err != nil && !errors.Is(err, ErrOrderStateChanged) {
+		tx.Rollback()
+		return "", err
+	}
+
+	child := model.NFTOrder{
+		OrderNo:       childNo,
+		NFTAssetID:    order.NFTAssetID,
+		TokenID:       order.TokenID,
+		ContractAddr:  order.ContractAddr,
+		TokenStandard: order.TokenStandard,
+		Quantity:      quantity,
+		FilledQty:     quantity,
+		ParentOrderNo: order.OrderNo,
+		SellerAddr:    order.SellerAddr,
+		BuyerAddr:     req.BuyerAddr,
+		Price:         totalPrice,
+		DealPrice:     totalPrice,
+		OrderType:     order.OrderType,
+		Status:        model.NFTOrderStatusProcessing,
+		ChainID:       order.ChainID,
+		StartTime:     time.Now(),
+		EndTime:       order.EndTime,
+	}
+	if err := tx.Create(&child).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("创建子订单失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+		return "", err
+	}
+	if err := recordStatusChange(tx, childNo, model.NFTOrderStatusNew, model.NFTOrderStatusProcessing, req.BuyerAddr, fmt.Sprintf("买家购买%d份，挂单：%s", quantity, order.OrderNo)); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+
+	// 3. 发布消息到RabbitMQ，异步执行交易（失败时子订单置为失败并归还挂单数量）
+	if err := utils.PublishTradeMsg(ctx, childNo); err != nil {
+		if failErr := failOrder(ctx, s.db, childNo, operatorExecuteTrade, "发布交易消息失败"); failErr != nil {
+			utils.Logger.Error("更新子订单失败状态失败", zap.String("order_no", childNo), zap.Error(failErr))
+		}
+		utils.Logger.Error("发布交易消息失败", zap.String("order_no", childNo), zap.Error(err))
+		return "", errors.New("发起交易失败，请稍后再试")
+	}
+
+	return childNo, nil
+}
+
+// releaseOrderAssets 订单终止（取消/过期/失败）时释放资产（需在事务内、订单状态变更后调用）
+// ERC-721订单解除资产锁定；ERC-1155挂单释放未售出数量；ERC-1155子订单将数量归还挂单，
+// 挂单已全部占用时恢复为待成交，挂单已终止时直接释放卖家锁定数量
+func releaseOrderAssets(tx *gorm.DB, orderNo, operator string) error {
+	var order model.NFTOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		return err
+	}
+	if order.TokenStandard != model.TokenStandardERC1155 {
+		unlockTime := time.Now()
+		return tx.Model(&model.NFTAssetLock{}).Where("order_no = ? AND unlock_time IS NULL", orderNo).Update("unlock_time", &unlockTime).Error
+	}
+	if order.ParentOrderNo == "" {
+		return unlockAssetBalance(tx, order.NFTAssetID, order.SellerAddr, order.Quantity-order.FilledQty)
+	}
+
+	var parent model.NFTOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_no = ?", order.ParentOrderNo).First(&parent).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&parent).Update("filled_qty", gorm.Expr("filled_qty - ?", order.Quantity)).Error; err != nil {
+		return err
+	}
+	switch parent.Status {
+	case model.NFTOrderStatusPending:
+		return nil
+	case model.NFTOrderStatusProcessing:
+		return transitionOrder(tx, orderTransition{
+			OrderNo:  parent.OrderNo,
+			From:     model.NFTOrderStatusProcessing,
+			To:       model.NFTOrderStatusPending,
+			Operator: operator,
+			Reason:   "子订单" + orderNo + "未成交，归还可售数量",
+		})
+	default:
+		return unlockAssetBalance(tx, order.NFTAssetID, order.SellerAddr, order.Quantity)
+	}
+}
+
+// settleERC1155Trade ERC-1155子订单交割成功后更新双方持有量，挂单全部成交时置为已成交（需在事务内调用）
+func settleERC1155Trade(tx *gorm.DB, order model.NFTOrder) error {
+	// 1. 扣减卖家持有量与锁定数量
+	if err := tx.Model(&model.NFTAssetBalance{}).
+		Where("nft_asset_id = ? AND owner_addr = ?", order.NFTAssetID, strings.ToLower(order.SellerAddr)).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("GREATEST(balance - ?, 0)", order.Quantity),
+			"locked_qty": gorm.Expr("GREATEST(locked_qty - ?, 0)", order.Quantity),
+		}).Error; err != nil {
+		return err
+	}
+
+	// 2. 增加买家持有量
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nft_asset_id"}, {Name: "owner_addr"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"balance": gorm.Expr("balance + ?", order.Quantity)}),
+	}).Create(&model.NFTAssetBalance{
+		NFTAssetID: order.NFTAssetID,
+		OwnerAddr:  strings.ToLower(order.BuyerAddr),
+		Balance:    order.Quantity,
+	}).Error; err != nil {
+		return err
+	}
+
+	// 3. 挂单数量全部交割完成时置为已成交
+	if order.ParentOrderNo == "" {
+		return nil
+	}
+	var parent model.NFTOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_no = ?", order.ParentOrderNo).First(&parent).Error; err != nil {
+		return err
+	}
+	var completedQty int64
+	if err := tx.Model(&model.NFTOrder{}).
+		Where("parent_order_no = ? AND status = ?", parent.OrderNo, model.NFTOrderStatusCompleted).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&completedQty).Error; err != nil {
+		return err
+	}
+	if completedQty < parent.Quantity || parent.Status != model.NFTOrderStatusProcessing {
+		return nil
+	}
+	return transitionOrder(tx, orderTransition{
+		OrderNo:  parent.OrderNo,
+		From:     model.NFTOrderStatusProcessing,
+		To:       model.NFTOrderStatusCompleted,
+		Operator: operatorExecuteTrade,
+		Reason:   "挂单数量已全部交割",
+	})
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ExpireWorker 过期订单清理任务
+// 定期将已超过EndTime的待成交订单置为已过期，并释放资产锁定；英式拍卖则进行结算
+type ExpireWorker struct {
+	db        *gorm.DB
+	interval  time.Duration
+	batchSize int
+}
+
+// NewExpireWorker 创建过期订单清理任务
+func NewExpireWorker(db *gorm.DB, interval time.Duration, batchSize int) *ExpireWorker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &ExpireWorker{
+		db:        db,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start 启动清理任务（阻塞，直到ctx取消）
+func (w *ExpireWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.Logger.Info("过期订单清理任务已停止")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce 执行一轮清理
+func (w *ExpireWorker) runOnce(ctx context.Context) {
+	// 分布式锁：多实例部署时仅一个实例执行清理；
+	// 锁有效期为扫描间隔的4倍，本轮处理在锁到期前一个间隔超时退出，锁不会在处理中途过期
+	lockTTL := 4 * w.interval
+	mutex, err := utils.TryRedisLock(ctx, "nft_order_expire_worker", lockTTL)
+	if err != nil {
+		return
+	}
+	defer utils.ReleaseRedisLock(mutex)
+	ctx, cancel := context.WithTimeout(ctx, lockTTL-w.interval)
+	defer cancel()
+
+	// 过期买家报价（报价不锁定资产，直接批量更新）
+	if n, err := expireOffers(ctx, w.db); err != nil {
+		utils.Logger.Error("过期报价失败", zap.Error(err))
+	} else if n > 0 {
+		utils.Logger.Info("报价已过期", zap.Int64("count", n))
+	}
+
+	for {
+		// 分批查询已过期的待成交订单
+		var orders []model.NFTOrder
+		if err := w.db.WithContext(ctx).
+			Where("status = ? AND end_time <= ?", model.NFTOrderStatusPending, time.Now()).
+			Order("end_time ASC").
+			Limit(w.batchSize).
+			Find(&orders).Error; err != nil {
+			utils.Logger.Error("查询过期订单失败", zap.Error(err))
+			return
+		}
+
+		handled := 0
+		for _, order := range orders {
+			if ctx.Err() != nil {
+				return
+			}
+			var err error
+			if order.OrderType == 1 {
+				// 英式拍卖到期结算
+				err = settleAuction(ctx, w.db, order)
+			} else {
+				err = w.expireOrder(ctx, order)
+			}
+			if err != nil {
+				utils.Logger.Error("处理过期订单失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+				continue
+			}
+			handled++
+		}
+
+		// 本批已处理完，或整批均失败（避免死循环，等待下一轮重试）
+		if len(orders) < w.batchSize || handled == 0 || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// expireOrder 过期单个订单：更新订单状态 + 解锁资产 + 发布过期事件
+func (w *ExpireWorker) expireOrder(ctx context.Context, order model.NFTOrder) error {
+	tx := w.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 条件更新：订单可能已被撮合/取消，此时跳过
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  order.OrderNo,
+		From:     model.NFTOrderStatusPending,
+		To:       model.NFTOrderStatusExpired,
+		Operator: operatorExpireWorker,
+		Reason:   "订单到期未成交",
+	}); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrOrderStateChanged) {
+			return nil
+		}
+		return err
+	}
+
+	// 解锁资产（ERC-1155释放未售出数量）
+	if err := releaseOrderAssets(tx, order.OrderNo, operatorExpireWorker); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	tx.Commit()
+
+	// 发布过期事件（失败仅记录日志，订单状态已落库）
+	if err := utils.PublishOrderEvent(ctx, "order.expired", order.OrderNo, map[string]interface{}{
+		"nft_asset_id": order.NFTAssetID,
+		"seller_addr":  order.SellerAddr,
+		"end_time":     order.EndTime,
+	}); err != nil {
+		utils.Logger.Error("发布订单过期事件失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+	}
+
+	utils.Logger.Info("订单已过期", zap.String("order_no", order.OrderNo))
+	return nil
+}
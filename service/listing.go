@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"nft_trade/model"
+
+	"gorm.io/gorm"
+)
+
+// priceExpr 价格为wei字符串，排序/比较时按DECIMAL处理
+const priceExpr = "CAST(price AS DECIMAL(65,0))"
+
+// -------------- 请求结构体 --------------
+// ListOrdersReq 查询挂单列表请求
+type ListOrdersReq struct {
+	ContractAddr string `json:"contract_addr"`
+	ChainID      int    `json:"chain_id"`
+	SellerAddr   string `json:"seller_addr"`
+	ViewerAddr   string `json:"-"`          // 取自登录会话（未登录为空），额外返回其作为卖家或指定买家的私人挂单
+	Status       *int   `json:"status"`     // 可选，默认0-待成交（仅返回已开售且未过期订单）
+	MinPrice     string `json:"min_price"`  // 可选，最低价格（wei单位）
+	MaxPrice     string `json:"max_price"`  // 可选，最高价格（wei单位）
+	SortBy       string `json:"sort_by"`    // price/created_at/end_time，默认created_at
+	SortOrder    string `json:"sort_order"` // asc/desc，默认desc
+	Cursor       string `json:"cursor"`     // 上一页返回的next_cursor，为空表示第一页
+	Limit        int    `json:"limit"`
+}
+
+// ListOrdersResp 查询挂单列表响应
+type ListOrdersResp struct {
+	List        []model.NFTOrder       `json:"list"`
+	NextCursor  string                 `json:"next_cursor"` // 为空表示没有更多数据
+	FloorPrices []CollectionFloorPrice `json:"floor_prices"`
+}
+
+// CollectionFloorPrice 合集地板价
+type CollectionFloorPrice struct {
+	ContractAddr string `json:"contract_addr"`
+	ChainID      int    `json:"chain_id"`
+	FloorPrice   string `json:"floor_price"`  // 最低一口价挂单价格（wei单位）
+	ListedCount  int64  `json:"listed_count"` // 在售数量
+}
+
+// listCursor 游标（排序字段值 + 订单ID）
+type listCursor struct {
+	Value string `json:"v"`
+	ID    uint64 `json:"id"`
+}
+
+// -------------- 核心方法 --------------
+// ListOrders 查询挂单列表（游标分页），同时返回结果涉及合集的地板价
+func (s *tradeService) ListOrders(ctx context.Context, req ListOrdersReq) (*ListOrdersResp, error) {
+	// 1. 校验排序参数
+	sortColumn := "created_at"
+	switch req.SortBy {
+	case "", "created_at":
+	case "price":
+		sortColumn = priceExpr
+	case "end_time":
+		sortColumn = "end_time"
+	default:
+		return nil, errors.New("不支持的排序字段")
+	}
+	desc := req.SortOrder != "asc"
+	if _, ok := parseWei(req.MinPrice); !ok {
+		return nil, errors.New("min_price参数错误")
+	}
+	if _, ok := parseWei(req.MaxPrice); !ok {
+		return nil, errors.New("max_price参数错误")
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+
+	// 2. 构建过滤条件
+	query := s.db.WithContext(ctx).Model(&model.NFTOrder{})
+	query = applyOrderFilters(query, req)
+
+	// 3. 游标条件：(排序值, ID) 严格位于上一页最后一条之后
+	if req.Cursor != "" {
+		cursor, err := decodeListCursor(req.Cursor)
+		if err != nil {
+			return nil, errors.New("cursor参数错误")
+		}
+		var value interface{} = cursor.Value
+		placeholder := "?"
+		if req.SortBy == "price" {
+			placeholder = "CAST(? AS DECIMAL(65,0))"
+		} else {
+			t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return nil, errors.New("cursor参数错误")
+			}
+			value = t
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where("(("+sortColumn+" "+op+" "+placeholder+") OR ("+sortColumn+" = "+placeholder+" AND id "+op+" ?))", value, value, cursor.ID)
+	}
+
+	// 4. 查询（多取一条用于判断是否还有下一页）
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	var orders []model.NFTOrder
+	if err := query.Order(sortColumn + direction).Order("id" + direction).Limit(req.Limit + 1).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &ListOrdersResp{List: orders}
+	if len(orders) > req.Limit {
+		resp.List = orders[:req.Limit]
+		last := resp.List[len(resp.List)-1]
+		var value string
+		switch req.SortBy {
+		case "price":
+			value = last.Price
+		case "end_time":
+			value = last.EndTime.Format(time.RFC3339Nano)
+		default:
+			value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		resp.NextCursor = encodeListCursor(listCursor{Value: value, ID: last.ID})
+	}
+
+	// 5. 查询结果涉及合集的地板价
+	floorPrices, err := s.getFloorPrices(ctx, req.ChainID, collectContracts(req.ContractAddr, resp.List))
+	if err != nil {
+		return nil, err
+	}
+	resp.FloorPrices = floorPrices
+
+	return resp, nil
+}
+
+// getFloorPrices 查询合集地板价（已开售、未过期的单个NFT公开一口价挂单最低价）
+func (s *tradeService) getFloorPrices(ctx context.Context, chainID int, contracts []string) ([]CollectionFloorPrice, error) {
+	floorPrices := []CollectionFloorPrice{}
+	if len(contracts) == 0 {
+		return floorPrices, nil
+	}
+
+	now := time.Now()
+	query := s.db.WithContext(ctx).Model(&model.NFTOrder{}).
+		Select("contract_addr, chain_id, CAST(MIN("+priceExpr+") AS CHAR) AS floor_price, COUNT(*) AS listed_count").
+		Where("status = ? AND order_type = 0 AND is_bundle = ? AND reserved_buyer = '' AND start_time <= ? AND end_time > ? AND contract_addr IN ?", model.NFTOrderStatusPending, false, now, now, contracts)
+	if chainID > 0 {
+		query = query.Where("chain_id = ?", chainID)
+	}
+	if err := query.Group("contract_addr, chain_id").Scan(&floorPrices).Error; err != nil {
+		return nil, err
+	}
+	return floorPrices, nil
+}
+
+// applyOrderFilters 挂单列表过滤条件
+func applyOrderFilters(query *gorm.DB, req ListOrdersReq) *gorm.DB {
+	status := model.NFTOrderStatusPending
+	if req.Status != nil {
+		status = model.NFTOrderStatus(*req.Status)
+	}
+	query = query.Where("status = ?", status)
+	if status == model.NFTOrderStatusPending {
+		// 定时挂单开售前不展示
+		now := time.Now()
+		query = query.Where("start_time <= ? AND end_time > ?", now, now)
+	}
+	if req.ContractAddr != "" {
+		query = query.Where("contract_addr = ?", req.ContractAddr)
+	}
+	if req.ChainID > 0 {
+		query = query.Where("chain_id = ?", req.ChainID)
+	}
+	if req.SellerAddr != "" {
+		query = query.Where("seller_addr = ?", req.SellerAddr)
+	}
+	// 私人挂单仅对卖家与指定买家可见
+	if req.ViewerAddr != "" {
+		query = query.Where("(reserved_buyer = '' OR reserved_buyer = ? OR seller_addr = ?)", req.ViewerAddr, req.ViewerAddr)
+	} else {
+		query = query.Where("reserved_buyer = ''")
+	}
+	if req.MinPrice != "" {
+		query = query.Where(priceExpr+" >= CAST(? AS DECIMAL(65,0))", req.MinPrice)
+	}
+	if req.MaxPrice != "" {
+		query = query.Where(priceExpr+" <= CAST(? AS DECIMAL(65,0))", req.MaxPrice)
+	}
+	return query
+}
+
+// collectContracts 收集需查询地板价的合约地址（去重）
+func collectContracts(contractAddr string, orders []model.NFTOrder) []string {
+	if contractAddr != "" {
+		return []string{contractAddr}
+	}
+	seen := make(map[string]bool)
+	contracts := make([]string, 0)
+	for _, order := range orders {
+		if order.ContractAddr == "" || seen[order.ContractAddr] {
+			continue
+		}
+		seen[order.ContractAddr] = true
+		contracts = append(contracts, order.ContractAddr)
+	}
+	return contracts
+}
+
+// encodeListCursor 编码游标
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor 解码游标
+func decodeListCursor(s string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListingScheduler 定时挂单开售任务
+// 定期扫描已到达StartTime的公开定时挂单，发布开售事件（私人挂单仅指定买家可见，不发布）
+type ListingScheduler struct {
+	db        *gorm.DB
+	interval  time.Duration
+	batchSize int
+}
+
+// NewListingScheduler 创建定时挂单开售任务
+func NewListingScheduler(db *gorm.DB, interval time.Duration, batchSize int) *ListingScheduler {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &ListingScheduler{
+		db:        db,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start 启动开售任务（阻塞，直到ctx取消）
+func (s *ListingScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.Logger.Info("定时挂单开售任务已停止")
+			return
+		case <-ticker.C:
+			s.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce 执行一轮扫描
+func (s *ListingScheduler) runOnce(ctx context.Context) {
+	// 分布式锁：多实例部署时仅一个实例发布事件
+	mutex, err := utils.TryRedisLock(ctx, "nft_listing_live_scheduler", s.interval)
+	if err != nil {
+		return
+	}
+	defer utils.ReleaseRedisLock(mutex)
+
+	for {
+		var orders []model.NFTOrder
+		if err := s.db.WithContext(ctx).
+			Where("pending_live = ? AND status = ? AND reserved_buyer = '' AND start_time <= ?", true, model.NFTOrderStatusPending, time.Now()).
+			Order("start_time ASC").
+			Limit(s.batchSize).
+			Find(&orders).Error; err != nil {
+			utils.Logger.Error("查询待开售订单失败", zap.Error(err))
+			return
+		}
+
+		handled := 0
+		for _, order := range orders {
+			if err := s.publishLive(ctx, order); err != nil {
+				utils.Logger.Error("发布开售事件失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+				continue
+			}
+			handled++
+		}
+
+		// 本批已处理完，或整批均失败（避免死循环，等待下一轮重试）
+		if len(orders) < s.batchSize || handled == 0 {
+			return
+		}
+	}
+}
+
+// publishLive 发布单个订单的开售事件，发布失败时恢复待发布标记以便下一轮重试
+func (s *ListingScheduler) publishLive(ctx context.Context, order model.NFTOrder) error {
+	// 条件更新：先清除标记，避免重复发布
+	result := s.db.WithContext(ctx).Model(&model.NFTOrder{}).Where("order_no = ? AND pending_live = ?", order.OrderNo, true).Update("pending_live", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := utils.PublishOrderEvent(ctx, "listing.live", order.OrderNo, map[string]interface{}{
+		"nft_asset_id":  order.NFTAssetID,
+		"contract_addr": order.ContractAddr,
+		"seller_addr":   order.SellerAddr,
+		"price":         order.Price,
+		"order_type":    order.OrderType,
+		"chain_id":      order.ChainID,
+		"start_time":    order.StartTime,
+		"end_time":      order.EndTime,
+	}); err != nil {
+		s.db.WithContext(ctx).Model(&model.NFTOrder{}).Where("order_no = ?", order.OrderNo).Update("pending_live", true)
+		return err
+	}
+
+	utils.Logger.Info("定时挂单已开售", zap.String("order_no", order.OrderNo))
+	return nil
+}
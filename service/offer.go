@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// -------------- 请求结构体 --------------
+// CreateOfferReq 创建报价请求
+type CreateOfferReq struct {
+	OfferType  int        `json:"offer_type"`   // 0-单个NFT 1-合集 2-特征
+	NFTAssetID uint64     `json:"nft_asset_id"` // 单个NFT报价必填
+	BuyerAddr  string     `json:"buyer_addr"`
+	Price      string     `json:"price"`       // 单个NFT报价金额（wei单位）
+	ExpireTime *time.Time `json:"expire_time"` // 可选，默认7天
+	// 合集/特征报价参数
+	ContractAddr string `json:"contract_addr"`
+	ChainID      int    `json:"chain_id"`
+	TraitType    string `json:"trait_type"`  // 特征报价必填
+	TraitValue   string `json:"trait_value"` // 特征报价必填
+	Quantity     int    `json:"quantity"`    // 求购数量，默认1
+}
+
+// CancelOfferReq 取消报价请求
+type CancelOfferReq struct {
+	OfferNo   string `json:"offer_no"`
+	BuyerAddr string `json:"buyer_addr"`
+}
+
+// AcceptOfferReq 接受报价请求
+type AcceptOfferReq struct {
+	OfferNo    string `json:"offer_no"`
+	SellerAddr string `json:"seller_addr"`  // NFT持有者地址
+	NFTAssetID uint64 `json:"nft_asset_id"` // 合集/特征报价必填，用于成交的NFT
+}
+
+// GetOffersReq 查询报价请求
+type GetOffersReq struct {
+	OwnerAddr  string `json:"owner_addr"` // NFT持有者地址（查询收到的报价）
+	BuyerAddr  string `json:"buyer_addr"` // 买家地址（查询发出的报价）
+	NFTAssetID uint64 `json:"nft_asset_id"`
+	Status     *int   `json:"status"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+}
+
+// GetBestCollectionOffersReq 查询合集最高报价请求
+type GetBestCollectionOffersReq struct {
+	ContractAddr string `json:"contract_addr"` // 可选，为空则返回所有合集
+	ChainID      int    `json:"chain_id"`      // 可选
+}
+
+// CollectionOfferSummary 合集最高报价
+type CollectionOfferSummary struct {
+	ContractAddr string `json:"contract_addr"`
+	ChainID      int    `json:"chain_id"`
+	BestPrice    string `json:"best_price"`    // 最高单价（wei单位）
+	OfferCount   int64  `json:"offer_count"`   // 有效报价数
+	RemainingQty int64  `json:"remaining_qty"` // 剩余求购总数
+}
+
+// -------------- 核心方法 --------------
+// CreateOffer 创建报价（NFT无论是否挂单均可报价）
+func (s *tradeService) CreateOffer(ctx context.Context, req CreateOfferReq) (string, error) {
+	// 1. 校验报价参数
+	price, ok := parseWei(req.Price)
+	if !ok || price.Sign() <= 0 {
+		return "", errors.New("报价金额格式错误")
+	}
+	expireTime := time.Now().Add(7 * 24 * time.Hour) // 默认7天
+	if req.ExpireTime != nil {
+		expireTime = *req.ExpireTime
+	}
+	if !expireTime.After(time.Now()) {
+		return "", errors.New("报价过期时间必须晚于当前时间")
+	}
+
+	switch req.OfferType {
+	case 0:
+	case 1, 2:
+		return s.createCollectionOffer(ctx, req, price.String(), expireTime)
+	default:
+		return "", errors.New("不支持的报价类型")
+	}
+
+	// 2. 校验NFT资产（ERC-1155暂不支持报价，与接受报价保持一致）
+	var asset model.NFTAsset
+	if err := s.db.WithContext(ctx).Where("id = ? AND token_standard = ? AND status = 0", req.NFTAssetID, model.TokenStandardERC721).First(&asset).Error; err != nil {
+		utils.Logger.Error("校验NFT资产失败", zap.Uint64("nft_asset_id", req.NFTAssetID), zap.Error(err))
+		return "", errors.New("NFT资产不存在或不属于当前用户，或资产状态异常（报价仅支持ERC-721资产）")
+	}
+	if strings.EqualFold(asset.OwnerAddr, req.BuyerAddr) {
+		return "", errors.New("不能对自己持有的NFT报价")
+	}
+
+	// 3. 创建报价
+	offer := model.NFTOffer{
+		OfferNo:      uuid.NewString(),
+		NFTAssetID:   asset.ID,
+		TokenID:      asset.TokenID,
+		ContractAddr: asset.ContractAddr,
+		BuyerAddr:    req.BuyerAddr,
+		Price:        price.String(),
+		Quantity:     1,
+		ChainID:      asset.ChainID,
+		Status:       model.OfferStatusPending,
+		ExpireTime:   expireTime,
+	}
+	if err := s.db.WithContext(ctx).Create(&offer).Error; err != nil {
+		utils.Logger.Error("创建报价失败", zap.Error(err))
+		return "", err
+	}
+
+	return offer.OfferNo, nil
+}
+
+// createCollectionOffer 创建合集/特征报价
+func (s *tradeService) createCollectionOffer(ctx context.Context, req CreateOfferReq, price string, expireTime time.Time) (string, error) {
+	if req.ContractAddr == "" || req.ChainID == 0 {
+		return "", errors.New("合集报价需指定合约地址和链ID")
+	}
+	if req.OfferType == 2 && (req.TraitType == "" || req.TraitValue == "") {
+		return "", errors.New("特征报价需指定特征类型和特征值")
+	}
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	offer := model.NFTOffer{
+		OfferNo:      uuid.NewString(),
+		OfferType:    req.OfferType,
+		ContractAddr: req.ContractAddr,
+		BuyerAddr:    req.BuyerAddr,
+		Price:        price,
+		Quantity:     quantity,
+		ChainID:      req.ChainID,
+		Status:       model.OfferStatusPending,
+		ExpireTime:   expireTime,
+	}
+	if req.OfferType == 2 {
+		offer.TraitType = req.TraitType
+		offer.TraitValue = req.TraitValue
+	}
+	if err := s.db.WithContext(ctx).Create(&offer).Error; err != nil {
+		utils.Logger.Error("创建合集报价失败", zap.Error(err))
+		return "", err
+	}
+
+	return offer.OfferNo, nil
+}
+
+// CancelOffer 取消报价
+func (s *tradeService) CancelOffer(ctx context.Context, req CancelOfferReq) error {
+	var offer model.NFTOffer
+	if err := s.db.WithContext(ctx).Where("offer_no = ?", req.OfferNo).First(&offer).Error; err != nil {
+		utils.Logger.Error("查询报价失败", zap.String("offer_no", req.OfferNo), zap.Error(err))
+		return errors.New("报价不存在")
+	}
+	if !strings.EqualFold(offer.BuyerAddr, req.BuyerAddr) {
+		return errors.New("无权取消他人报价")
+	}
+
+	// 条件更新：仅待接受状态可取消，防止与并发接受冲突
+	result := s.db.WithContext(ctx).Model(&model.NFTOffer{}).Where("offer_no = ? AND status = ?", req.OfferNo, model.OfferStatusPending).Update("status", model.OfferStatusCancelled)
+	if result.Error != nil {
+		utils.Logger.Error("取消报价失败", zap.String("offer_no", req.OfferNo), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("报价状态不允许取消")
+	}
+
+	return nil
+}
+
+// AcceptOffer 持有者接受报价，生成处理中订单并发起链上交割
+func (s *tradeService) AcceptOffer(ctx context.Context, req AcceptOfferReq) (string, error) {
+	// 1. 校验报价：待接受、未过期
+	var offer model.NFTOffer
+	if err := s.db.WithContext(ctx).Where("offer_no = ? AND status = ? AND expire_time > ?", req.OfferNo, model.OfferStatusPending, time.Now()).First(&offer).Error; err != nil {
+		utils.Logger.Error("校验报价失败", zap.String("offer_no", req.OfferNo), zap.Error(err))
+		return "", errors.New("报价不存在或已失效")
+	}
+
+	// 2. 校验NFT资产为接受者持有的ERC-721资产（合集/特征报价由接受者指定NFT；ERC-1155暂不支持报价）
+	nftAssetID := offer.NFTAssetID
+	if offer.OfferType != 0 {
+		nftAssetID = req.NFTAssetID
+	}
+	var asset model.NFTAsset
+	if err := s.db.WithContext(ctx).Where("id = ? AND owner_addr = ? AND token_standard = ? AND status = 0", nftAssetID, req.SellerAddr, model.TokenStandardERC721).First(&asset).Error; err != nil {
+		utils.Logger.Error("校验NFT资产失败", zap.Uint64("nft_asset_id", nftAssetID), zap.String("seller_addr", req.SellerAddr), zap.Error(err))
+		return "", errors.New("NFT资产不存在或不属于当前用户，或资产状态异常（报价仅支持ERC-721资产）")
+	}
+	if strings.EqualFold(asset.OwnerAddr, offer.BuyerAddr) {
+		return "", errors.New("不能接受自己的报价")
+	}
+	if err := matchOfferAsset(ctx, offer, asset); err != nil {
+		return "", err
+	}
+
+	// 3. 校验链上所有权与操作员授权
+	if err := checkListingOnChain(ctx, asset.ChainID, req.SellerAddr, listingOperator(asset.ChainID, false), []model.NFTAsset{asset}); err != nil {
+		return "", err
+	}
+
+	// 4. 分布式锁：与挂单共用资产锁，防止并发挂单/接受
+	lockKey := fmt.Sprintf("nft_lock_%d", asset.ID)
+	mutex, err := utils.GetRedisLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		utils.Logger.Error("获取分布式锁失败", zap.String("lockKey", lockKey), zap.Error(err))
+		return "", errors.New("当前资产正在处理中，请稍后再试")
+	}
+	defer utils.ReleaseRedisLock(mutex)
+
+	// 5. 资产已挂单时，接受报价将下架原挂单
+	var listing *model.NFTOrder
+	var lockRecord model.NFTAssetLock
+	if err := s.db.WithContext(ctx).Where("nft_asset_id = ? AND unlock_time IS NULL", asset.ID).First(&lockRecord).Error; err == nil {
+		var order model.NFTOrder
+		if err := s.db.WithContext(ctx).Where("order_no = ?", lockRecord.OrderNo).First(&order).Error; err != nil {
+			return "", err
+		}
+		if order.Status != model.NFTOrderStatusPending || order.OrderType == 1 {
+			return "", errors.New("NFT资产正在交易或拍卖中，无法接受报价")
+		}
+		if order.IsBundle {
+			// 下架组合挂单会连带解锁组合内其他NFT，须由卖家先取消组合挂单
+			return "", errors.New("NFT资产在组合挂单中，请先取消组合挂单再接受报价")
+		}
+		listing = &order
+	}
+
+	// 6. 构建订单（按报价成交）
+	orderNo := uuid.NewString()
+	now := time.Now()
+	order := model.NFTOrder{
+		OrderNo:      orderNo,
+		NFTAssetID:   asset.ID,
+		TokenID:      asset.TokenID,
+		ContractAddr: asset.ContractAddr,
+		SellerAddr:   req.SellerAddr,
+		BuyerAddr:    offer.BuyerAddr,
+		Price:        offer.Price,
+		OrderType:    0,
+		Status:       model.NFTOrderStatusProcessing,
+		ChainID:      asset.ChainID,
+		StartTime:    now,
+		EndTime:      now,
+	}
+
+	// 7. 事务：资产锁定转移至新订单 + 接受报价 + 创建订单
+	// 原挂单保持待成交，交易消息发布成功后才下架；失去资产锁定的挂单不可购买（见MatchOrder），发布失败时锁定归还原挂单
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if listing != nil {
+		if err := tx.Model(&model.NFTAssetLock{}).Where("order_no = ? AND unlock_time IS NULL", listing.OrderNo).Update("unlock_time", &now).Error; err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	// 条件更新：成交数量+1，全部成交后报价置为已接受
+	result := tx.Model(&model.NFTOffer{}).Where("offer_no = ? AND status = ? AND filled_qty < quantity", req.OfferNo, model.OfferStatusPending).Updates(map[string]interface{}{
+		"filled_qty": gorm.Expr("filled_qty + 1"),
+		"order_no":   orderNo,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return "", errors.New("报价状态已变更，无法接受")
+	}
+	if err := tx.Model(&model.NFTOffer{}).Where("offer_no = ? AND filled_qty >= quantity", req.OfferNo).Update("status", model.OfferStatusAccepted).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("创建订单失败", zap.Error(err))
+		return "", err
+	}
+
+	if err := lockAsset(tx, asset.ID, orderNo, 0); err != nil {
+		tx.Rollback()
+		utils.Logger.Error("锁定资产失败", zap.Error(err))
+		return "", err
+	}
+
+	if err := recordStatusChange(tx, orderNo, model.NFTOrderStatusNew, model.NFTOrderStatusProcessing, req.SellerAddr, "接受报价，报价编号："+req.OfferNo); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	tx.Commit()
+
+	// 8. 发布消息到RabbitMQ，异步执行交易
+	if err := utils.PublishTradeMsg(ctx, orderNo); err != nil {
+		// 订单置为失败，解锁资产，报价回退本次成交数量，资产锁定归还仍待成交的原挂单
+		if failErr := failOrder(ctx, s.db, orderNo, req.SellerAddr, "发布交易消息失败"); failErr != nil {
+			utils.Logger.Error("更新订单失败状态失败", zap.String("order_no", orderNo), zap.Error(failErr))
+		} else if listing != nil {
+			if restoreErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				var current model.NFTOrder
+				if err := tx.Select("status").Where("order_no = ?", listing.OrderNo).First(&current).Error; err != nil {
+					return err
+				}
+				if current.Status != model.NFTOrderStatusPending {
+					return nil
+				}
+				return lockAsset(tx, asset.ID, listing.OrderNo, 0)
+			}); restoreErr != nil {
+				utils.Logger.Error("恢复原挂单资产锁定失败", zap.String("order_no", listing.OrderNo), zap.Error(restoreErr))
+			}
+		}
+		if err := s.db.WithContext(ctx).Model(&model.NFTOffer{}).Where("offer_no = ? AND order_no = ? AND filled_qty > 0", req.OfferNo, orderNo).Updates(map[string]interface{}{
+			"filled_qty": gorm.Expr("filled_qty - 1"),
+			"status":     model.OfferStatusPending,
+		}).Error; err != nil {
+			utils.Logger.Error("回退报价成交数量失败", zap.String("offer_no", req.OfferNo), zap.Error(err))
+		}
+		utils.Logger.Error("发布交易消息失败", zap.String("order_no", orderNo), zap.Error(err))
+		return "", errors.New("发起交易失败，请稍后再试")
+	}
+
+	// 9. 下架原挂单（期间卖家已取消或已过期时无需处理）
+	if listing != nil {
+		if err := transitionOrderTx(ctx, s.db, orderTransition{
+			OrderNo:  listing.OrderNo,
+			From:     model.NFTOrderStatusPending,
+			To:       model.NFTOrderStatusCancelled,
+			Operator: req.SellerAddr,
+			Reason:   "卖家接受报价，原挂单下架",
+		}); err != nil && !errors.Is(err, ErrOrderStateChanged) {
+			utils.Logger.Error("下架原挂单失败", zap.String("order_no", listing.OrderNo), zap.Error(err))
+		}
+	}
+
+	utils.Logger.Info("报价已接受", zap.String("offer_no", req.OfferNo), zap.String("order_no", orderNo))
+	return orderNo, nil
+}
+
+// GetOffers 查询报价列表
+func (s *tradeService) GetOffers(ctx context.Context, req GetOffersReq) ([]model.NFTOffer, int64, error) {
+	var offers []model.NFTOffer
+	var total int64
+
+	// 构建查询条件
+	query := s.db.WithContext(ctx).Model(&model.NFTOffer{})
+	if req.OwnerAddr != "" {
+		// 单个NFT报价按持有资产匹配，合集/特征报价按持有资产所属合约匹配
+		query = query.Where("(offer_type = 0 AND nft_asset_id IN (?)) OR (offer_type > 0 AND contract_addr IN (?))",
+			s.db.Model(&model.NFTAsset{}).Select("id").Where("owner_addr = ?", req.OwnerAddr),
+			s.db.Model(&model.NFTAsset{}).Select("contract_addr").Where("owner_addr = ?", req.OwnerAddr))
+	}
+	if req.BuyerAddr != "" {
+		query = query.Where("buyer_addr = ?", req.BuyerAddr)
+	}
+	if req.NFTAssetID > 0 {
+		query = query.Where("nft_asset_id = ?", req.NFTAssetID)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	// 统计总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Offset(offset).Limit(req.PageSize).Order("created_at DESC").Find(&offers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return offers, total, nil
+}
+
+// GetBestCollectionOffers 查询各合集当前最高的有效合集报价
+func (s *tradeService) GetBestCollectionOffers(ctx context.Context, req GetBestCollectionOffersReq) ([]CollectionOfferSummary, error) {
+	var summaries []CollectionOfferSummary
+
+	// 价格为wei字符串，按DECIMAL比较大小
+	query := s.db.WithContext(ctx).Model(&model.NFTOffer{}).
+		Select("contract_addr, chain_id, CAST(MAX(CAST(price AS DECIMAL(65,0))) AS CHAR) AS best_price, COUNT(*) AS offer_count, SUM(quantity - filled_qty) AS remaining_qty").
+		Where("offer_type = 1 AND status = ? AND expire_time > ?", model.OfferStatusPending, time.Now())
+	if req.ContractAddr != "" {
+		query = query.Where("contract_addr = ?", req.ContractAddr)
+	}
+	if req.ChainID > 0 {
+		query = query.Where("chain_id = ?", req.ChainID)
+	}
+
+	if err := query.Group("contract_addr, chain_id").Order("contract_addr").Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// matchOfferAsset 校验NFT是否满足报价条件
+func matchOfferAsset(ctx context.Context, offer model.NFTOffer, asset model.NFTAsset) error {
+	switch offer.OfferType {
+	case 0:
+		if asset.ID != offer.NFTAssetID {
+			return errors.New("NFT与报价不匹配")
+		}
+	case 1, 2:
+		if !strings.EqualFold(asset.ContractAddr, offer.ContractAddr) || asset.ChainID != offer.ChainID {
+			return errors.New("NFT不属于报价指定的合集")
+		}
+		if offer.OfferType == 2 {
+			metadata, err := utils.FetchNFTMetadata(ctx, config.GlobalConfig.IPFSGateway, asset.MetadataCID)
+			if err != nil {
+				utils.Logger.Error("获取NFT元数据失败", zap.Uint64("nft_asset_id", asset.ID), zap.Error(err))
+				return errors.New("获取NFT元数据失败，请稍后再试")
+			}
+			if !metadata.HasTrait(offer.TraitType, offer.TraitValue) {
+				return fmt.Errorf("NFT不具备特征%s=%s", offer.TraitType, offer.TraitValue)
+			}
+		}
+	}
+	return nil
+}
+
+// expireOffers 批量将已过期的待接受报价置为已过期
+func expireOffers(ctx context.Context, db *gorm.DB) (int64, error) {
+	result := db.WithContext(ctx).Model(&model.NFTOffer{}).Where("status = ? AND expire_time <= ?", model.OfferStatusPending, time.Now()).Update("status", model.OfferStatusExpired)
+	return result.RowsAffected, result.Error
+}
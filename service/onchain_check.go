@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"nft_trade/config"
+	"nft_trade/contract"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotTokenOwner 链上持有者与卖家不一致
+	ErrNotTokenOwner = errors.New("卖家不是该NFT的链上持有者")
+	// ErrNotApproved 卖家未授权市场操作员转移NFT
+	ErrNotApproved = errors.New("卖家未授权市场转移该NFT，请先执行setApprovalForAll")
+	// ErrOperatorNotConfigured 链未配置交割操作员，挂单无法在链上交割
+	ErrOperatorNotConfigured = errors.New("该链暂不支持挂单：未配置市场交割操作员")
+)
+
+// listingOperator 挂单交割时转移NFT的操作员：组合订单为批量转账辅助合约，其余为市场操作员
+func listingOperator(chainID int, isBundle bool) string {
+	if isBundle {
+		return config.GlobalConfig.BatchTransferAddr[chainID]
+	}
+	return config.GlobalConfig.OperatorAddr[chainID]
+}
+
+// checkListingOnChain 挂单前校验卖家为各NFT的链上持有者，且已授权操作员转移（未配置操作员时挂单无法交割，直接拒绝）
+func checkListingOnChain(ctx context.Context, chainID int, sellerAddr, operator string, assets []model.NFTAsset) error {
+	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[chainID]
+	if !ok {
+		return errors.New("链配置不存在")
+	}
+	if operator == "" {
+		utils.Logger.Error("未配置交割操作员，拒绝挂单", zap.Int("chain_id", chainID))
+		return ErrOperatorNotConfigured
+	}
+
+	transactors := make(map[string]*contract.ERC721Transactor)
+	defer func() {
+		for _, transactor := range transactors {
+			transactor.Close()
+		}
+	}()
+	approvedForAll := make(map[string]bool)
+	for _, asset := range assets {
+		contractKey := strings.ToLower(asset.ContractAddr)
+		transactor, ok := transactors[contractKey]
+		if !ok {
+			var err error
+			transactor, err = contract.NewERC721Transactor(rpcUrl, asset.ContractAddr)
+			if err != nil {
+				return fmt.Errorf("连接区块链节点失败：%w", err)
+			}
+			transactors[contractKey] = transactor
+		}
+
+		// 1. 校验链上持有者
+		owner, err := transactor.OwnerOf(ctx, asset.TokenID)
+		if err != nil {
+			return fmt.Errorf("查询NFT链上持有者失败：%w", err)
+		}
+		if !strings.EqualFold(owner.Hex(), sellerAddr) {
+			utils.Logger.Warn("NFT链上持有者与卖家不一致", zap.Uint64("nft_asset_id", asset.ID), zap.String("seller_addr", sellerAddr), zap.String("owner", owner.Hex()))
+			return fmt.Errorf("%w（TokenID：%s）", ErrNotTokenOwner, asset.TokenID)
+		}
+
+		// 2. 校验操作员授权（整合约授权或单个NFT授权）
+		approved, checked := approvedForAll[contractKey]
+		if !checked {
+			approved, err = transactor.IsApprovedForAll(ctx, sellerAddr, operator)
+			if err != nil {
+				return fmt.Errorf("查询NFT授权失败：%w", err)
+			}
+			approvedForAll[contractKey] = approved
+		}
+		if approved {
+			continue
+		}
+		spender, err := transactor.GetApproved(ctx, asset.TokenID)
+		if err != nil {
+			return fmt.Errorf("查询NFT授权失败：%w", err)
+		}
+		if spender != common.HexToAddress(operator) {
+			utils.Logger.Warn("卖家未授权操作员转移NFT", zap.Uint64("nft_asset_id", asset.ID), zap.String("seller_addr", sellerAddr), zap.String("operator", operator))
+			return fmt.Errorf("%w（TokenID：%s）", ErrNotApproved, asset.TokenID)
+		}
+	}
+	return nil
+}
+
+// checkERC1155ListingOnChain 挂单前校验卖家链上持有量不少于已锁定数量加本次挂单数量，且已授权操作员转移，返回链上持有量
+func checkERC1155ListingOnChain(ctx context.Context, chainID int, sellerAddr, operator string, asset model.NFTAsset, lockedQty, quantity int64) (int64, error) {
+	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[chainID]
+	if !ok {
+		return 0, errors.New("链配置不存在")
+	}
+	if operator == "" {
+		utils.Logger.Error("未配置交割操作员，拒绝挂单", zap.Int("chain_id", chainID))
+		return 0, ErrOperatorNotConfigured
+	}
+	transactor, err := contract.NewERC1155Transactor(rpcUrl, asset.ContractAddr)
+	if err != nil {
+		return 0, fmt.Errorf("连接区块链节点失败：%w", err)
+	}
+	defer transactor.Close()
+
+	// 1. 校验链上持有量
+	balance, err := transactor.BalanceOf(ctx, sellerAddr, asset.TokenID)
+	if err != nil {
+		return 0, fmt.Errorf("查询NFT链上持有量失败：%w", err)
+	}
+	if !balance.IsInt64() || balance.Int64() < lockedQty+quantity {
+		utils.Logger.Warn("NFT链上持有量不足", zap.Uint64("nft_asset_id", asset.ID), zap.String("seller_addr", sellerAddr), zap.String("balance", balance.String()), zap.Int64("locked_qty", lockedQty), zap.Int64("quantity", quantity))
+		return 0, fmt.Errorf("%w（TokenID：%s，持有量：%s）", ErrNotTokenOwner, asset.TokenID, balance.String())
+	}
+
+	// 2. 校验操作员授权（ERC-1155仅支持整合约授权）
+	approved, err := transactor.IsApprovedForAll(ctx, sellerAddr, operator)
+	if err != nil {
+		return 0, fmt.Errorf("查询NFT授权失败：%w", err)
+	}
+	if !approved {
+		utils.Logger.Warn("卖家未授权操作员转移NFT", zap.Uint64("nft_asset_id", asset.ID), zap.String("seller_addr", sellerAddr), zap.String("operator", operator))
+		return 0, fmt.Errorf("%w（TokenID：%s）", ErrNotApproved, asset.TokenID)
+	}
+	return balance.Int64(), nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"nft_trade/config"
+	"nft_trade/contract"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+)
+
+var (
+	operatorSignerMu sync.RWMutex
+	operatorSigners  = make(map[int]contract.Signer)
+)
+
+// InitOperatorSigners 按配置创建各链市场操作员签名器（启动时解锁keystore）
+// 未配置操作员地址的链以签名账户地址作为操作员地址，已配置时须与签名账户一致；
+// 已配置交易所合约（开放挂单）的链须配置签名器，否则挂单永远无法交割
+func InitOperatorSigners() error {
+	cfg := config.GlobalConfig
+	for chainID := range cfg.ChainRPCUrl {
+		keystorePath := cfg.OperatorKeystore[chainID]
+		remoteURL := cfg.OperatorRemoteSigner[chainID]
+		if keystorePath == "" && remoteURL == "" && cfg.ExchangeAddr[chainID] != "" {
+			return fmt.Errorf("链%d已配置交易所合约，须配置操作员keystore或远程签名服务", chainID)
+		}
+
+		var signer contract.Signer
+		var err error
+		switch {
+		case keystorePath != "" && remoteURL != "":
+			return fmt.Errorf("链%d同时配置了keystore与远程签名服务", chainID)
+		case keystorePath != "":
+			signer, err = contract.NewKeystoreSigner(keystorePath, cfg.OperatorKeystorePassword)
+		case remoteURL != "":
+			if cfg.OperatorAddr[chainID] == "" {
+				return fmt.Errorf("链%d使用远程签名服务时须配置操作员地址", chainID)
+			}
+			signer, err = contract.NewRemoteSigner(remoteURL, cfg.OperatorAddr[chainID], cfg.RemoteSignerToken)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("初始化链%d操作员签名器失败：%w", chainID, err)
+		}
+
+		addr := signer.Address().Hex()
+		if cfg.OperatorAddr[chainID] == "" {
+			cfg.OperatorAddr[chainID] = addr
+		} else if !strings.EqualFold(cfg.OperatorAddr[chainID], addr) {
+			return fmt.Errorf("链%d操作员签名账户与操作员地址不一致", chainID)
+		}
+		SetOperatorSigner(chainID, signer)
+		utils.Logger.Info("市场操作员签名器已就绪", zap.Int("chain_id", chainID), zap.String("operator", addr))
+	}
+	return nil
+}
+
+// SetOperatorSigner 指定链使用的市场操作员签名器（如密钥轮换、替换为HSM实现）
+func SetOperatorSigner(chainID int, signer contract.Signer) {
+	operatorSignerMu.Lock()
+	defer operatorSignerMu.Unlock()
+	operatorSigners[chainID] = signer
+}
+
+// getOperatorSigner 获取链对应的市场操作员签名器
+// 同一操作员账户的交易nonce由节点PendingNonceAt分配，签名至广播须持有nft_operator_send_{chainID}锁串行执行
+func getOperatorSigner(chainID int) (contract.Signer, error) {
+	operatorSignerMu.RLock()
+	defer operatorSignerMu.RUnlock()
+	signer, ok := operatorSigners[chainID]
+	if !ok {
+		return nil, errors.New("市场操作员账户配置不存在")
+	}
+	return signer, nil
+}
@@ -1,29 +1,33 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"nft_trade/dao"
 	"nft_trade/model"
 	"nft_trade/utils"
-	"strconv"
+	"strings"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 // PlaceOrder 挂单
-func PlaceOrder(nftId, userAddr string, price int64, quantity int64, orderType model.OrderType, signature string) (string, error) {
+// 签名支持EOA与合约钱包（ERC-1271在chainID对应链上校验），待签消息含时间戳，有效期内同一消息只能使用一次
+func PlaceOrder(ctx context.Context, chainID int, nftId, userAddr string, price int64, quantity int64, orderType model.OrderType, timestamp int64, signature string) (string, error) {
 
 	lock, err := utils.NewRedisLock("XXXX", "XXXX", 0)
 
 	// 1. 前置校验
-	// 1.1 签名验签
-	data := nftId + userAddr + strconv.FormatInt(price, 10) + strconv.FormatInt(quantity, 10) + string(orderType)
-	if !utils.VerifySignature(userAddr, data, signature) {
-		return "", fmt.Errorf("signature verify failed")
+	// 1.1 签名验签（校验时间戳并防重放）
+	data := orderBookPlaceMessage(chainID, nftId, userAddr, price, quantity, orderType, timestamp)
+	if err := verifySignedRequest(ctx, chainID, userAddr, data, timestamp, signature); err != nil {
+		return "", fmt.Errorf("signature verify failed: %w", err)
 	}
 	// 1.2 资产校验（简化版：实际需检查用户是否持有NFT/资金充足）
 	if orderType == model.OrderTypeSell {
 		// 检查用户是否持有该NFT且未被冻结
-		if !checkUserNFTAvailable(userAddr, nftId) {
+		if !checkUserNFTAvailable(ctx, chainID, userAddr, nftId, quantity) {
 			return "", fmt.Errorf("user not own nft or nft is frozen")
 		}
 	} else {
@@ -85,13 +89,14 @@ func PlaceOrder(nftId, userAddr string, price int64, quantity int64, orderType m
 }
 
 // CancelOrder 撤单
-func CancelOrder(orderId, userAddr, signature string) error {
+// 签名支持EOA与合约钱包（ERC-1271在chainID对应链上校验），待签消息含时间戳，有效期内同一消息只能使用一次
+func CancelOrder(ctx context.Context, chainID int, orderId, userAddr string, timestamp int64, signature string) error {
 	lock, err := utils.NewRedisLock("XXXX", "XXXX", 0)
 	// 1. 前置校验
-	// 1.1 签名验签
-	data := orderId + userAddr
-	if !utils.VerifySignature(userAddr, data, signature) {
-		return fmt.Errorf("signature verify failed")
+	// 1.1 签名验签（校验时间戳并防重放）
+	data := orderBookCancelMessage(chainID, orderId, userAddr, timestamp)
+	if err := verifySignedRequest(ctx, chainID, userAddr, data, timestamp, signature); err != nil {
+		return fmt.Errorf("signature verify failed: %w", err)
 	}
 	// 1.2 查询订单
 	order, err := dao.GetOrderById(orderId)
@@ -99,7 +104,7 @@ func CancelOrder(orderId, userAddr, signature string) error {
 		return fmt.Errorf("order not found: %v", err)
 	}
 	// 1.3 校验订单归属
-	if order.UserAddr != userAddr {
+	if !strings.EqualFold(order.UserAddr, userAddr) {
 		return fmt.Errorf("user not owner of order")
 	}
 	// 1.4 校验订单状态
@@ -137,12 +142,66 @@ func CancelOrder(orderId, userAddr, signature string) error {
 	return nil
 }
 
-// 以下为简化版辅助函数，实际需根据业务实现
-func checkUserNFTAvailable(userAddr, nftId string) bool {
-	// 检查用户是否持有该NFT且未被冻结
-	return true
+// orderBookPlaceMessage 订单簿挂单待签消息（personal_sign，格式同MatchOrderMessage）
+func orderBookPlaceMessage(chainID int, nftId, userAddr string, price, quantity int64, orderType model.OrderType, timestamp int64) string {
+	return strings.Join([]string{
+		"NFT Trade: place order",
+		fmt.Sprintf("chain_id: %d", chainID),
+		fmt.Sprintf("user: %s", strings.ToLower(userAddr)),
+		fmt.Sprintf("nft_id: %s", nftId),
+		fmt.Sprintf("type: %s", orderType),
+		fmt.Sprintf("price: %d", price),
+		fmt.Sprintf("quantity: %d", quantity),
+		fmt.Sprintf("timestamp: %d", timestamp),
+	}, "\n")
+}
+
+// orderBookCancelMessage 订单簿撤单待签消息
+func orderBookCancelMessage(chainID int, orderId, userAddr string, timestamp int64) string {
+	return strings.Join([]string{
+		"NFT Trade: cancel order",
+		fmt.Sprintf("chain_id: %d", chainID),
+		fmt.Sprintf("user: %s", strings.ToLower(userAddr)),
+		fmt.Sprintf("order_id: %s", orderId),
+		fmt.Sprintf("timestamp: %d", timestamp),
+	}, "\n")
+}
+
+// checkUserNFTAvailable 检查用户是否持有该NFT且未被冻结、锁定，并已授权市场操作员转移
+// ERC-721校验链上持有者与授权；ERC-1155校验链上可售数量（持有量扣除已锁定数量）与授权
+func checkUserNFTAvailable(ctx context.Context, chainID int, userAddr, nftId string, quantity int64) bool {
+	asset, err := dao.GetNFTAssetById(nftId)
+	if err != nil {
+		utils.Logger.Warn("查询NFT资产失败", zap.String("nft_id", nftId), zap.Error(err))
+		return false
+	}
+	if asset.Status != 0 || asset.ChainID != chainID {
+		return false
+	}
+	operator := listingOperator(chainID, false)
+
+	if asset.TokenStandard == model.TokenStandardERC1155 {
+		lockedQty, err := dao.GetNFTAssetLockedQty(asset.ID, userAddr)
+		if err != nil {
+			utils.Logger.Warn("查询NFT锁定数量失败", zap.String("nft_id", nftId), zap.Error(err))
+			return false
+		}
+		_, err = checkERC1155ListingOnChain(ctx, chainID, userAddr, operator, *asset, lockedQty, quantity)
+		return err == nil
+	}
+
+	if quantity != 1 || !strings.EqualFold(asset.OwnerAddr, userAddr) {
+		return false
+	}
+	locked, err := dao.IsNFTAssetLocked(asset.ID)
+	if err != nil || locked {
+		return false
+	}
+	return checkListingOnChain(ctx, chainID, userAddr, operator, []model.NFTAsset{*asset}) == nil
 }
 
+// 以下为简化版辅助函数，实际需根据业务实现
+
 func checkUserFundAvailable(userAddr string, amount int64) bool {
 	// 检查用户资金是否充足
 	return true
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// -------------- 请求结构体 --------------
+// UserNonceResp 用户签名挂单参数
+type UserNonceResp struct {
+	UserAddr  string `json:"user_addr"`
+	Counter   uint64 `json:"counter"`    // 当前订单计数器，签名挂单须携带
+	NextNonce uint64 `json:"next_nonce"` // 建议使用的下一个nonce
+}
+
+// CancelAllOrdersReq 批量取消全部订单请求（递增订单计数器）
+type CancelAllOrdersReq struct {
+	SellerAddr string `json:"seller_addr"`
+	Counter    uint64 `json:"counter"`  // 卖家当前计数器，防止重复递增
+	ChainID    int    `json:"chain_id"` // 可选，合约钱包所在链ID（EOA钱包无需填写）
+	Timestamp  int64  `json:"timestamp"`
+	Signature  string `json:"signature"` // 卖家对CancelAllOrdersMessage的personal_sign签名
+}
+
+// CancelAllOrdersResp 批量取消全部订单结果
+type CancelAllOrdersResp struct {
+	Counter uint64            `json:"counter"` // 递增后的计数器
+	Results []BatchItemResult `json:"results"` // 待成交订单逐项取消结果
+}
+
+// -------------- 核心方法 --------------
+// GetUserNonce 查询用户当前计数器及建议nonce
+func (s *tradeService) GetUserNonce(ctx context.Context, userAddr string) (*UserNonceResp, error) {
+	counter, err := s.getUserCounter(ctx, userAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxNonce struct {
+		Nonce *uint64
+	}
+	if err := s.db.WithContext(ctx).Model(&model.NFTUserNonce{}).Select("MAX(nonce) AS nonce").Where("user_addr = ?", strings.ToLower(userAddr)).Scan(&maxNonce).Error; err != nil {
+		return nil, err
+	}
+	nextNonce := uint64(0)
+	if maxNonce.Nonce != nil {
+		nextNonce = *maxNonce.Nonce + 1
+	}
+
+	return &UserNonceResp{
+		UserAddr:  userAddr,
+		Counter:   counter,
+		NextNonce: nextNonce,
+	}, nil
+}
+
+// CancelAllOrders 递增卖家订单计数器，此前签名的订单全部失效，并下架其待成交订单
+// 已有出价的拍卖等无法取消的订单在结果中返回失败，但签名订单因计数器失效已无法成交
+func (s *tradeService) CancelAllOrders(ctx context.Context, req CancelAllOrdersReq) (*CancelAllOrdersResp, error) {
+	if err := verifySignedRequest(ctx, req.ChainID, req.SellerAddr, CancelAllOrdersMessage(req), req.Timestamp, req.Signature); err != nil {
+		return nil, err
+	}
+
+	// 1. 条件递增计数器（以请求中的当前值为条件，防止并发重复递增）
+	userAddr := strings.ToLower(req.SellerAddr)
+	var result *gorm.DB
+	if req.Counter == 0 {
+		// 首次递增：计数器记录可能不存在
+		var userCounter model.NFTUserCounter
+		err := s.db.WithContext(ctx).Where("user_addr = ?", userAddr).First(&userCounter).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result = s.db.WithContext(ctx).Create(&model.NFTUserCounter{UserAddr: userAddr, Counter: 1})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if result == nil {
+		result = s.db.WithContext(ctx).Model(&model.NFTUserCounter{}).Where("user_addr = ? AND counter = ?", userAddr, req.Counter).Update("counter", gorm.Expr("counter + 1"))
+	}
+	if result.Error != nil {
+		utils.Logger.Error("递增订单计数器失败", zap.String("seller_addr", req.SellerAddr), zap.Error(result.Error))
+		return nil, ErrCounterMismatch
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrCounterMismatch
+	}
+	newCounter := req.Counter + 1
+
+	// 2. 下架计数器递增前创建的待成交订单
+	var orderNos []string
+	if err := s.db.WithContext(ctx).Model(&model.NFTOrder{}).
+		Where("seller_addr = ? AND status = ? AND counter < ?", req.SellerAddr, model.NFTOrderStatusPending, newCounter).
+		Pluck("order_no", &orderNos).Error; err != nil {
+		return nil, err
+	}
+	results := make([]BatchItemResult, len(orderNos))
+	for i, orderNo := range orderNos {
+		results[i].OrderNo = orderNo
+		if err := s.CancelSellOrder(ctx, CancelSellOrderReq{OrderNo: orderNo, SellerAddr: req.SellerAddr}); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Success = true
+	}
+
+	utils.Logger.Info("卖家已批量取消订单", zap.String("seller_addr", req.SellerAddr), zap.Uint64("counter", newCounter), zap.Int("order_count", len(orderNos)))
+	return &CancelAllOrdersResp{Counter: newCounter, Results: results}, nil
+}
+
+// CancelAllOrdersMessage 批量取消全部订单待签消息
+func CancelAllOrdersMessage(req CancelAllOrdersReq) string {
+	return strings.Join([]string{
+		"NFT Trade: cancel all orders",
+		fmt.Sprintf("seller: %s", strings.ToLower(req.SellerAddr)),
+		fmt.Sprintf("counter: %d", req.Counter),
+		fmt.Sprintf("timestamp: %d", req.Timestamp),
+	}, "\n")
+}
+
+// getUserCounter 查询用户当前订单计数器（无记录为0）
+func (s *tradeService) getUserCounter(ctx context.Context, userAddr string) (uint64, error) {
+	var userCounter model.NFTUserCounter
+	err := s.db.WithContext(ctx).Where("user_addr = ?", strings.ToLower(userAddr)).First(&userCounter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return userCounter.Counter, nil
+}
+
+// verifyOrderSignature 校验已落库签名订单的卖家签名及计数器
+func (s *tradeService) verifyOrderSignature(ctx context.Context, order model.NFTOrder) error {
+	typed := typedOrderFromModel(order)
+	if err := verifyTypedDataSignature(ctx, order.ChainID, order.SellerAddr, typed.TypedData(), order.Signature); err != nil {
+		utils.Logger.Error("订单签名校验失败", zap.String("order_no", order.OrderNo), zap.Error(err))
+		return err
+	}
+	counter, err := s.getUserCounter(ctx, order.SellerAddr)
+	if err != nil {
+		return err
+	}
+	if order.Counter != counter {
+		return errors.New("订单已被卖家批量取消")
+	}
+	return nil
+}
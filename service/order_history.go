@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 系统操作方标识
+const (
+	operatorExpireWorker    = "system:expire_worker"
+	operatorAuctionSettle   = "system:auction_settle"
+	operatorExecuteTrade    = "system:execute_trade"
+	operatorTransferIndexer = "system:transfer_indexer"
+	operatorConfirmWorker   = "system:confirm_worker"
+)
+
+// OrderDetailResp 订单详情（含资产、锁定记录、成交记录及状态时间线）
+type OrderDetailResp struct {
+	Order       model.NFTOrder                `json:"order"`
+	Assets      []model.NFTAsset              `json:"assets"` // 单个订单为1个，组合订单为全部明细
+	Items       []model.NFTOrderItem          `json:"items,omitempty"`
+	Locks       []model.NFTAssetLock          `json:"locks"`
+	TradeRecord *model.NFTTradeRecord         `json:"trade_record"` // 未成交时为空
+	Timeline    []model.NFTOrderStatusHistory `json:"timeline"`
+}
+
+// GetOrderDetail 查询订单详情
+func (s *tradeService) GetOrderDetail(ctx context.Context, orderNo string) (*OrderDetailResp, error) {
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		utils.Logger.Error("查询订单失败", zap.String("order_no", orderNo), zap.Error(err))
+		return nil, errors.New("订单不存在")
+	}
+	resp := &OrderDetailResp{Order: order}
+
+	// 1. 关联资产
+	assets, err := s.loadOrderAssets(ctx, order)
+	if err != nil {
+		utils.Logger.Warn("查询订单资产失败", zap.String("order_no", orderNo), zap.Error(err))
+		assets = []model.NFTAsset{}
+	}
+	resp.Assets = assets
+	if order.IsBundle {
+		if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).Find(&resp.Items).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 锁定记录（锁记录按资产复用，仅能查到仍归属本订单的记录）
+	if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).Find(&resp.Locks).Error; err != nil {
+		return nil, err
+	}
+
+	// 3. 成交记录
+	var records []model.NFTTradeRecord
+	if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) > 0 {
+		resp.TradeRecord = &records[0]
+	}
+
+	// 4. 状态时间线（历史订单无变更记录时，以创建时间补一条创建记录）
+	if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).Order("id ASC").Find(&resp.Timeline).Error; err != nil {
+		return nil, err
+	}
+	if len(resp.Timeline) == 0 {
+		resp.Timeline = []model.NFTOrderStatusHistory{{
+			OrderNo:    orderNo,
+			FromStatus: model.NFTOrderStatusNew,
+			ToStatus:   model.NFTOrderStatusPending,
+			Operator:   order.SellerAddr,
+			CreatedAt:  order.CreatedAt,
+		}}
+	}
+
+	return resp, nil
+}
+
+// recordStatusChange 记录订单状态变更（需与状态更新在同一事务内调用，状态更新见transitionOrder）
+func recordStatusChange(tx *gorm.DB, orderNo string, fromStatus, toStatus model.NFTOrderStatus, operator, reason string) error {
+	return tx.Create(&model.NFTOrderStatusHistory{
+		OrderNo:    orderNo,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		Operator:   operator,
+		Reason:     reason,
+	}).Error
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"nft_trade/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIllegalTransition 状态机不允许的订单状态变更
+	ErrIllegalTransition = errors.New("非法的订单状态变更")
+	// ErrOrderStateChanged 订单当前状态与预期不符（已被并发修改）
+	ErrOrderStateChanged = errors.New("订单状态已变更")
+)
+
+// OrderTransitionError 订单状态变更失败
+type OrderTransitionError struct {
+	OrderNo string
+	From    model.NFTOrderStatus // 预期的变更前状态
+	To      model.NFTOrderStatus
+	Current model.NFTOrderStatus // 订单实际状态（订单不存在时为NFTOrderStatusNew）
+	Err     error                // ErrIllegalTransition 或 ErrOrderStateChanged
+}
+
+func (e *OrderTransitionError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrIllegalTransition):
+		return fmt.Sprintf("订单状态不允许从%s变更为%s", e.From, e.To)
+	case e.Current == model.NFTOrderStatusNew:
+		return "订单不存在"
+	case e.Current != e.From:
+		return fmt.Sprintf("订单当前状态为%s，无法变更为%s", e.Current, e.To)
+	default:
+		return "订单已变更，请刷新后重试"
+	}
+}
+
+func (e *OrderTransitionError) Unwrap() error {
+	return e.Err
+}
+
+// orderTransitions 合法的订单状态变更
+// 处理中 -> 待成交 仅用于发布交易消息失败后的回滚，及ERC-1155子订单失败后挂单恢复可售
+var orderTransitions = map[model.NFTOrderStatus][]model.NFTOrderStatus{
+	model.NFTOrderStatusNew:        {model.NFTOrderStatusPending, model.NFTOrderStatusProcessing},
+	model.NFTOrderStatusPending:    {model.NFTOrderStatusProcessing, model.NFTOrderStatusCancelled, model.NFTOrderStatusExpired},
+	model.NFTOrderStatusProcessing: {model.NFTOrderStatusCompleted, model.NFTOrderStatusFailed, model.NFTOrderStatusPending, model.NFTOrderStatusConfirming},
+	model.NFTOrderStatusConfirming: {model.NFTOrderStatusCompleted, model.NFTOrderStatusFailed},
+}
+
+// canTransition 判断状态变更是否合法
+func canTransition(from, to model.NFTOrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// orderTransition 一次订单状态变更
+type orderTransition struct {
+	OrderNo  string
+	From     model.NFTOrderStatus
+	To       model.NFTOrderStatus
+	Operator string
+	Reason   string
+	Updates  map[string]interface{} // 可选，随状态一并更新的字段
+	Where    string                 // 可选，附加条件（如价格未变更、拍卖已到期）
+	Args     []interface{}
+}
+
+// transitionOrder 执行订单状态变更并记录历史（需在事务内调用）
+// 以 status = From 为条件更新，未命中时返回 *OrderTransitionError
+func transitionOrder(tx *gorm.DB, t orderTransition) error {
+	if !canTransition(t.From, t.To) {
+		return &OrderTransitionError{OrderNo: t.OrderNo, From: t.From, To: t.To, Current: t.From, Err: ErrIllegalTransition}
+	}
+
+	updates := map[string]interface{}{"status": t.To}
+	for column, value := range t.Updates {
+		updates[column] = value
+	}
+	query := tx.Model(&model.NFTOrder{}).Where("order_no = ? AND status = ?", t.OrderNo, t.From)
+	if t.Where != "" {
+		query = query.Where(t.Where, t.Args...)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		current := model.NFTOrderStatusNew
+		var order model.NFTOrder
+		if err := tx.Select("status").Where("order_no = ?", t.OrderNo).First(&order).Error; err == nil {
+			current = order.Status
+		}
+		return &OrderTransitionError{OrderNo: t.OrderNo, From: t.From, To: t.To, Current: current, Err: ErrOrderStateChanged}
+	}
+
+	return recordStatusChange(tx, t.OrderNo, t.From, t.To, t.Operator, t.Reason)
+}
+
+// transitionOrderTx 在独立事务中执行订单状态变更（用于事务外的补偿回滚）
+func transitionOrderTx(ctx context.Context, db *gorm.DB, t orderTransition) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return transitionOrder(tx, t)
+	})
+}
+
+// failOrder 处理中的订单置为失败并释放资产锁定（独立事务）
+func failOrder(ctx context.Context, db *gorm.DB, orderNo, operator, reason string) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transitionOrder(tx, orderTransition{
+			OrderNo:  orderNo,
+			From:     model.NFTOrderStatusProcessing,
+			To:       model.NFTOrderStatusFailed,
+			Operator: operator,
+			Reason:   reason,
+		}); err != nil {
+			return err
+		}
+		return releaseOrderAssets(tx, orderNo, operator)
+	})
+}
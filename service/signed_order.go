@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// signatureValidity 签名有效期（签名时间戳与服务器时间的最大偏差）
+const signatureValidity = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature 签名校验失败
+	ErrInvalidSignature = errors.New("签名校验失败")
+	// ErrSignatureExpired 签名已过期或时间戳非法
+	ErrSignatureExpired = errors.New("签名已过期")
+	// ErrSignatureReused 签名已被使用（重放）
+	ErrSignatureReused = errors.New("签名已被使用")
+	// ErrCounterMismatch 签名计数器与卖家当前计数器不一致（已批量取消）
+	ErrCounterMismatch = errors.New("签名计数器已失效，请重新签名")
+	// ErrNonceUsed 签名nonce已被使用
+	ErrNonceUsed = errors.New("签名nonce已被使用")
+)
+
+// -------------- 请求结构体 --------------
+// SignedCreateSellOrderReq EIP-712签名挂单请求（公开一口价挂单）
+type SignedCreateSellOrderReq struct {
+	NFTAssetID uint64 `json:"nft_asset_id"`
+	SellerAddr string `json:"seller_addr"`
+	ChainID    int    `json:"chain_id"`
+	Price      string `json:"price"`
+	Currency   string `json:"currency"`   // 可选，计价代币地址，默认原生代币
+	StartTime  int64  `json:"start_time"` // 开售时间（Unix秒），晚于当前时间即为定时挂单
+	EndTime    int64  `json:"end_time"`   // 结束时间（Unix秒）
+	Salt       string `json:"salt"`       // 随机盐（uint256十进制）
+	Nonce      uint64 `json:"nonce"`      // 卖家签名nonce，每个nonce只能挂单一次
+	Counter    uint64 `json:"counter"`    // 卖家当前订单计数器
+	Signature  string `json:"signature"`  // 卖家对TypedOrder的eth_signTypedData_v4签名
+}
+
+// SignedMatchOrderReq 带钱包签名的购买请求
+type SignedMatchOrderReq struct {
+	MatchOrderReq
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"` // 买家对MatchOrderMessage的personal_sign签名
+}
+
+// SignedCancelSellOrderReq 带钱包签名的取消出售订单请求
+type SignedCancelSellOrderReq struct {
+	CancelSellOrderReq
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"` // 卖家对CancelOrderMessage的personal_sign签名
+}
+
+// -------------- 核心方法 --------------
+// CreateSignedSellOrder 校验卖家EIP-712签名后创建出售订单，签名随订单落库
+func (s *tradeService) CreateSignedSellOrder(ctx context.Context, req SignedCreateSellOrderReq) (string, error) {
+	// 1. 校验签名参数
+	if req.Currency == "" {
+		req.Currency = nativeCurrency
+	}
+	if !common.IsHexAddress(req.Currency) {
+		return "", errors.New("计价代币地址格式错误")
+	}
+	if salt, ok := parseWei(req.Salt); !ok || salt.Sign() <= 0 {
+		return "", errors.New("salt格式错误")
+	}
+	if req.StartTime <= 0 || req.EndTime <= req.StartTime {
+		return "", errors.New("开售/结束时间错误")
+	}
+	if !time.Unix(req.EndTime, 0).After(time.Now()) {
+		return "", errors.New("签名订单已过期")
+	}
+
+	// 2. 查询资产（签名覆盖合约地址与TokenID）
+	var asset model.NFTAsset
+	if err := s.db.WithContext(ctx).Where("id = ?", req.NFTAssetID).First(&asset).Error; err != nil {
+		return "", errors.New("NFT资产不存在")
+	}
+
+	// 3. 校验EIP-712签名
+	typed := TypedOrder{
+		ChainID:    req.ChainID,
+		Seller:     req.SellerAddr,
+		Collection: asset.ContractAddr,
+		TokenID:    asset.TokenID,
+		Price:      req.Price,
+		Currency:   req.Currency,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Salt:       req.Salt,
+		Nonce:      req.Nonce,
+		Counter:    req.Counter,
+	}
+	if err := verifyTypedDataSignature(ctx, req.ChainID, req.SellerAddr, typed.TypedData(), req.Signature); err != nil {
+		utils.Logger.Warn("EIP-712签名校验失败", zap.String("seller_addr", req.SellerAddr), zap.Uint64("nft_asset_id", req.NFTAssetID), zap.Error(err))
+		return "", err
+	}
+
+	// 4. 校验计数器与nonce（nonce在创建订单事务内占用）
+	counter, err := s.getUserCounter(ctx, req.SellerAddr)
+	if err != nil {
+		return "", err
+	}
+	if req.Counter != counter {
+		return "", ErrCounterMismatch
+	}
+
+	startTime := time.Unix(req.StartTime, 0)
+	endTime := time.Unix(req.EndTime, 0)
+	return s.CreateSellOrder(ctx, CreateSellOrderReq{
+		NFTAssetID: req.NFTAssetID,
+		SellerAddr: req.SellerAddr,
+		Price:      req.Price,
+		OrderType:  0,
+		ChainID:    req.ChainID,
+		StartTime:  &startTime,
+		EndTime:    &endTime,
+		typed:      &typed,
+		signature:  req.Signature,
+	})
+}
+
+// MatchSignedOrder 校验买家签名后购买订单（签名须包含买家确认的价格，荷兰式拍卖为最高价）
+func (s *tradeService) MatchSignedOrder(ctx context.Context, req SignedMatchOrderReq) (string, error) {
+	if req.Price == "" {
+		return "", errors.New("签名购买须指定价格")
+	}
+	chainID, err := s.getOrderChainID(ctx, req.OrderNo)
+	if err != nil {
+		return "", err
+	}
+	if err := verifySignedRequest(ctx, chainID, req.BuyerAddr, MatchOrderMessage(chainID, req.MatchOrderReq, req.Timestamp), req.Timestamp, req.Signature); err != nil {
+		return "", err
+	}
+	return s.MatchOrder(ctx, req.MatchOrderReq)
+}
+
+// CancelSignedSellOrder 校验卖家签名后取消出售订单
+func (s *tradeService) CancelSignedSellOrder(ctx context.Context, req SignedCancelSellOrderReq) error {
+	chainID, err := s.getOrderChainID(ctx, req.OrderNo)
+	if err != nil {
+		return err
+	}
+	if err := verifySignedRequest(ctx, chainID, req.SellerAddr, CancelOrderMessage(chainID, req.CancelSellOrderReq, req.Timestamp), req.Timestamp, req.Signature); err != nil {
+		return err
+	}
+	return s.CancelSellOrder(ctx, req.CancelSellOrderReq)
+}
+
+// -------------- 待签消息 --------------
+// 购买/取消的待签消息为多行文本，钱包以personal_sign（EIP-191）签名，客户端须按相同格式拼接
+// 挂单使用EIP-712结构化签名，见TypedOrder
+
+// MatchOrderMessage 购买订单待签消息（含订单所在链ID，防止跨链重放）
+// ERC-1155购买数量大于1时追加quantity行（兼容已有客户端的单件购买消息）
+func MatchOrderMessage(chainID int, req MatchOrderReq, timestamp int64) string {
+	lines := []string{
+		"NFT Trade: buy order",
+		fmt.Sprintf("chain_id: %d", chainID),
+		fmt.Sprintf("buyer: %s", strings.ToLower(req.BuyerAddr)),
+		fmt.Sprintf("order_no: %s", req.OrderNo),
+		fmt.Sprintf("price: %s", req.Price),
+	}
+	if req.Quantity > 1 {
+		lines = append(lines, fmt.Sprintf("quantity: %d", req.Quantity))
+	}
+	lines = append(lines, fmt.Sprintf("timestamp: %d", timestamp))
+	return strings.Join(lines, "\n")
+}
+
+// CancelOrderMessage 取消出售订单待签消息（含订单所在链ID，防止跨链重放）
+func CancelOrderMessage(chainID int, req CancelSellOrderReq, timestamp int64) string {
+	return strings.Join([]string{
+		"NFT Trade: cancel sell order",
+		fmt.Sprintf("chain_id: %d", chainID),
+		fmt.Sprintf("seller: %s", strings.ToLower(req.SellerAddr)),
+		fmt.Sprintf("order_no: %s", req.OrderNo),
+		fmt.Sprintf("timestamp: %d", timestamp),
+	}, "\n")
+}
+
+// getOrderChainID 查询订单所在链ID（合约钱包签名须在该链上校验）
+func (s *tradeService) getOrderChainID(ctx context.Context, orderNo string) (int, error) {
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Select("chain_id").Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		return 0, errors.New("订单不存在")
+	}
+	return order.ChainID, nil
+}
+
+// verifySignedRequest 校验签名时间戳、签名者地址（合约钱包在chainID对应链上校验），并防止签名重放
+func verifySignedRequest(ctx context.Context, chainID int, signerAddr, message string, timestamp int64, signature string) error {
+	// 防重放依赖Redis记录已使用的消息，未初始化时拒绝请求（不可跳过重放校验）
+	if utils.RedisClient == nil {
+		return errors.New("Redis未初始化，无法校验签名")
+	}
+	signedAt := time.Unix(timestamp, 0)
+	if timestamp <= 0 || time.Since(signedAt) > signatureValidity || time.Until(signedAt) > signatureValidity {
+		return ErrSignatureExpired
+	}
+	if err := verifyMessageSignature(ctx, chainID, signerAddr, message, signature); err != nil {
+		utils.Logger.Warn("签名校验失败", zap.String("signer_addr", signerAddr), zap.Error(err))
+		return err
+	}
+
+	// 有效期内同一消息只能使用一次（按消息而非签名去重，避免签名延展性绕过）
+	digest := sha256.Sum256([]byte(message))
+	key := fmt.Sprintf("nft_sig_used_%s", hex.EncodeToString(digest[:]))
+	ok, err := utils.RedisClient.SetNX(ctx, key, 1, 2*signatureValidity).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSignatureReused
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"nft_trade/config"
@@ -12,6 +13,8 @@ import (
 	"nft_trade/model"
 	"nft_trade/utils"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -21,8 +24,29 @@ import (
 type TradeService interface {
 	CreateSellOrder(ctx context.Context, req CreateSellOrderReq) (string, error)
 	MatchOrder(ctx context.Context, req MatchOrderReq) (string, error)
+	CancelSellOrder(ctx context.Context, req CancelSellOrderReq) error
+	CreateSignedSellOrder(ctx context.Context, req SignedCreateSellOrderReq) (string, error)
+	MatchSignedOrder(ctx context.Context, req SignedMatchOrderReq) (string, error)
+	CancelSignedSellOrder(ctx context.Context, req SignedCancelSellOrderReq) error
+	GetUserNonce(ctx context.Context, userAddr string) (*UserNonceResp, error)
+	CancelAllOrders(ctx context.Context, req CancelAllOrdersReq) (*CancelAllOrdersResp, error)
+	UpdateSellOrder(ctx context.Context, req UpdateSellOrderReq) error
+	BatchCreateSellOrder(ctx context.Context, req BatchCreateSellOrderReq) ([]BatchItemResult, error)
+	BatchCancelSellOrder(ctx context.Context, req BatchCancelSellOrderReq) ([]BatchItemResult, error)
+	CreateBundleOrder(ctx context.Context, req CreateBundleOrderReq) (string, error)
+	GetPriceHistory(ctx context.Context, orderNo string) ([]model.NFTOrderPriceHistory, error)
+	GetOrderDetail(ctx context.Context, orderNo string) (*OrderDetailResp, error)
 	ExecuteTrade(ctx context.Context, orderNo string) error
 	GetTradeRecords(ctx context.Context, req GetTradeRecordsReq) ([]model.NFTTradeRecord, int64, error)
+	ListOrders(ctx context.Context, req ListOrdersReq) (*ListOrdersResp, error)
+	PlaceBid(ctx context.Context, req PlaceBidReq) (string, error)
+	GetBids(ctx context.Context, req GetBidsReq) ([]model.NFTBid, int64, error)
+	GetDutchPrice(ctx context.Context, orderNo string) (*DutchPriceResp, error)
+	CreateOffer(ctx context.Context, req CreateOfferReq) (string, error)
+	CancelOffer(ctx context.Context, req CancelOfferReq) error
+	AcceptOffer(ctx context.Context, req AcceptOfferReq) (string, error)
+	GetOffers(ctx context.Context, req GetOffersReq) ([]model.NFTOffer, int64, error)
+	GetBestCollectionOffers(ctx context.Context, req GetBestCollectionOffersReq) ([]CollectionOfferSummary, error)
 }
 
 // tradeService 交易服务实现
@@ -45,13 +69,44 @@ type CreateSellOrderReq struct {
 	Price      string     `json:"price"`
 	OrderType  int        `json:"order_type"` // 0-一口价 1-英式拍卖 2-荷兰式拍卖
 	ChainID    int        `json:"chain_id"`
-	EndTime    *time.Time `json:"end_time"` // 可选，默认7天
+	Quantity   int64      `json:"quantity"`   // 可选，挂单数量（仅ERC-1155，默认1，Price为单价）
+	StartTime  *time.Time `json:"start_time"` // 可选，定时开售时间，默认立即开售
+	EndTime    *time.Time `json:"end_time"`   // 可选，默认开售后7天
+	// 私人挂单参数（仅OrderType=0时有效）
+	ReservedBuyer string `json:"reserved_buyer"` // 可选，指定买家地址，仅该地址可购买，且不在公开列表展示
+	// 英式拍卖参数（OrderType=1时有效，Price为起拍价）
+	ReservePrice string `json:"reserve_price"` // 可选，保留价
+	MinIncrement string `json:"min_increment"` // 可选，最小加价幅度
+	// 荷兰式拍卖参数（OrderType=2时有效，Price为起始价）
+	EndPrice   string `json:"end_price"`   // 结束价
+	DecayCurve int    `json:"decay_curve"` // 降价曲线 0-线性 1-指数
+	// EIP-712签名挂单（仅CreateSignedSellOrder内部使用）
+	typed     *TypedOrder
+	signature string
+	// ERC-1155卖家链上持有量（仅createERC1155SellOrder内部使用）
+	balance int64
 }
 
 // MatchOrderReq 撮合订单请求（买家购买）
 type MatchOrderReq struct {
 	OrderNo   string `json:"order_no"`
 	BuyerAddr string `json:"buyer_addr"`
+	Price     string `json:"price"`    // 可选，买家确认的挂单价格，与当前价格不一致时拒绝成交（荷兰式拍卖为可接受的最高价）
+	Quantity  int64  `json:"quantity"` // 可选，购买数量（仅ERC-1155，默认1）
+}
+
+// CancelSellOrderReq 取消出售订单请求
+type CancelSellOrderReq struct {
+	OrderNo    string `json:"order_no"`
+	SellerAddr string `json:"seller_addr"`
+}
+
+// UpdateSellOrderReq 修改出售订单请求（改价/修改结束时间）
+type UpdateSellOrderReq struct {
+	OrderNo    string     `json:"-"` // 取自路由参数
+	SellerAddr string     `json:"seller_addr"`
+	Price      string     `json:"price"`    // 可选，新价格（wei单位）
+	EndTime    *time.Time `json:"end_time"` // 可选，新结束时间
 }
 
 // GetTradeRecordsReq 查询交易记录请求
@@ -65,14 +120,24 @@ type GetTradeRecordsReq struct {
 // -------------- 核心方法 --------------
 // CreateSellOrder 创建出售订单
 func (s *tradeService) CreateSellOrder(ctx context.Context, req CreateSellOrderReq) (string, error) {
-	// 1. 校验NFT资产是否存在且属于卖家
+	// 1. 校验NFT资产是否存在且属于卖家（ERC-1155按链上持有量校验）
 	var asset model.NFTAsset
-	if err := s.db.WithContext(ctx).Where("id = ? AND owner_addr = ? AND status = 0", req.NFTAssetID, req.SellerAddr).First(&asset).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("id = ? AND status = 0", req.NFTAssetID).First(&asset).Error; err != nil ||
+		(asset.TokenStandard != model.TokenStandardERC1155 && !strings.EqualFold(asset.OwnerAddr, req.SellerAddr)) {
 		utils.Logger.Error("校验NFT资产失败", zap.Uint64("nft_asset_id", req.NFTAssetID), zap.String("seller_addr", req.SellerAddr), zap.Error(err))
 		return "", errors.New("NFT资产不存在或不属于当前用户，或资产状态异常")
 	}
+	if asset.TokenStandard == model.TokenStandardERC1155 {
+		return s.createERC1155SellOrder(ctx, req, asset)
+	}
+
+	// 2-3. 校验挂单参数与链上所有权、操作员授权（尽早失败，避免交割时才暴露）
+	lockType, err := validateERC721Listing(ctx, req, asset)
+	if err != nil {
+		return "", err
+	}
 
-	// 2. 分布式锁：防止并发挂单（锁10秒）
+	// 4. 分布式锁：防止并发挂单（锁10秒）
 	lockKey := fmt.Sprintf("nft_lock_%d", req.NFTAssetID)
 	mutex, err := utils.GetRedisLock(ctx, lockKey, 10*time.Second)
 	if err != nil {
@@ -81,34 +146,86 @@ func (s *tradeService) CreateSellOrder(ctx context.Context, req CreateSellOrderR
 	}
 	defer utils.ReleaseRedisLock(mutex)
 
-	// 3. 校验资产是否已被锁定
+	// 5. 校验资产是否已被锁定
 	var lockRecord model.NFTAssetLock
 	if err := s.db.WithContext(ctx).Where("nft_asset_id = ? AND unlock_time IS NULL", req.NFTAssetID).First(&lockRecord).Error; err == nil {
 		return "", errors.New("NFT资产已被锁定，无法挂单")
 	}
 
-	// 4. 构建订单
-	orderNo := uuid.NewString()                   // 生成唯一订单号
-	endTime := time.Now().Add(7 * 24 * time.Hour) // 默认7天
+	// 6. 创建订单并锁定资产
+	return s.createSellOrder(ctx, req, asset, lockType)
+}
+
+// validateERC721Listing 校验ERC-721挂单：资产所属链、挂单数量、订单类型与价格参数，及链上所有权与操作员授权，返回资产锁定类型
+// 单个挂单与批量挂单共用（含RPC查询，须在持有资产锁前调用）
+func validateERC721Listing(ctx context.Context, req CreateSellOrderReq, asset model.NFTAsset) (int, error) {
+	if asset.ChainID != req.ChainID {
+		return 0, errors.New("NFT资产不属于挂单指定的链")
+	}
+	if req.Quantity > 1 {
+		return 0, errors.New("ERC-721资产挂单数量只能为1")
+	}
+	lockType, err := validateSellOrderReq(req)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkListingOnChain(ctx, req.ChainID, req.SellerAddr, listingOperator(req.ChainID, false), []model.NFTAsset{asset}); err != nil {
+		return 0, err
+	}
+	return lockType, nil
+}
+
+// createSellOrder 构建订单并在事务中创建订单、锁定资产（调用方需已持有资产分布式锁）
+func (s *tradeService) createSellOrder(ctx context.Context, req CreateSellOrderReq, asset model.NFTAsset, lockType int) (string, error) {
+	// 1. 构建订单
+	orderNo := uuid.NewString() // 生成唯一订单号
+	startTime := time.Now()
+	pendingLive := false
+	if req.StartTime != nil && req.StartTime.After(startTime) {
+		// 定时挂单：立即锁定资产，开售前不可购买、不展示；私人挂单不公开，不发布开售事件
+		startTime = *req.StartTime
+		pendingLive = req.ReservedBuyer == ""
+	}
+	endTime := startTime.Add(7 * 24 * time.Hour) // 默认开售后7天
 	if req.EndTime != nil {
 		endTime = *req.EndTime
 	}
 
 	order := model.NFTOrder{
-		OrderNo:      orderNo,
-		NFTAssetID:   req.NFTAssetID,
-		TokenID:      asset.TokenID,
-		ContractAddr: asset.ContractAddr,
-		SellerAddr:   req.SellerAddr,
-		Price:        req.Price,
-		OrderType:    req.OrderType,
-		Status:       0, // 待成交
-		ChainID:      req.ChainID,
-		StartTime:    time.Now(),
-		EndTime:      endTime,
-	}
-
-	// 5. 事务：创建订单 + 锁定资产
+		OrderNo:       orderNo,
+		NFTAssetID:    req.NFTAssetID,
+		TokenID:       asset.TokenID,
+		ContractAddr:  asset.ContractAddr,
+		TokenStandard: asset.TokenStandard,
+		Quantity:      1,
+		SellerAddr:    req.SellerAddr,
+		ReservedBuyer: req.ReservedBuyer,
+		Price:         req.Price,
+		OrderType:     req.OrderType,
+		ReservePrice:  req.ReservePrice,
+		MinIncrement:  req.MinIncrement,
+		EndPrice:      req.EndPrice,
+		DecayCurve:    req.DecayCurve,
+		Status:        model.NFTOrderStatusPending,
+		ChainID:       req.ChainID,
+		StartTime:     startTime,
+		PendingLive:   pendingLive,
+		EndTime:       endTime,
+	}
+	if req.typed != nil {
+		// 签名挂单：开售时间须与签名一致（签名开售时间已过时不改为当前时间）
+		order.StartTime = time.Unix(req.typed.StartTime, 0)
+		order.Currency = req.typed.Currency
+		order.Salt = req.typed.Salt
+		order.Nonce = req.typed.Nonce
+		order.Counter = req.typed.Counter
+		order.Signature = req.signature
+	}
+	if req.Quantity > 0 {
+		order.Quantity = req.Quantity
+	}
+
+	// 2. 事务：创建订单 + 锁定资产
 	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -123,19 +240,38 @@ func (s *tradeService) CreateSellOrder(ctx context.Context, req CreateSellOrderR
 		return "", err
 	}
 
-	// 锁定资产
-	lockRecord = model.NFTAssetLock{
-		NFTAssetID: req.NFTAssetID,
-		OrderNo:    orderNo,
-		LockType:   0, // 交易挂单
-		LockTime:   time.Now(),
+	// 锁定资产（ERC-1155锁定挂单数量）
+	var err error
+	if asset.TokenStandard == model.TokenStandardERC1155 {
+		err = lockAssetBalance(tx, req.NFTAssetID, req.SellerAddr, req.balance, order.Quantity)
+	} else {
+		err = lockAsset(tx, req.NFTAssetID, orderNo, lockType)
 	}
-	if err := tx.Create(&lockRecord).Error; err != nil {
+	if err != nil {
 		tx.Rollback()
 		utils.Logger.Error("锁定资产失败", zap.Error(err))
 		return "", err
 	}
 
+	// 签名挂单：占用nonce（唯一索引，防止同一签名重复挂单）
+	if req.typed != nil {
+		if err := tx.Create(&model.NFTUserNonce{
+			UserAddr: strings.ToLower(req.SellerAddr),
+			Nonce:    req.typed.Nonce,
+			OrderNo:  orderNo,
+		}).Error; err != nil {
+			tx.Rollback()
+			utils.Logger.Warn("占用签名nonce失败", zap.String("seller_addr", req.SellerAddr), zap.Uint64("nonce", req.typed.Nonce), zap.Error(err))
+			return "", ErrNonceUsed
+		}
+	}
+
+	// 记录状态变更
+	if err := recordStatusChange(tx, orderNo, model.NFTOrderStatusNew, model.NFTOrderStatusPending, req.SellerAddr, "卖家挂单"); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
 	tx.Commit()
 
 	return orderNo, nil
@@ -145,31 +281,106 @@ func (s *tradeService) CreateSellOrder(ctx context.Context, req CreateSellOrderR
 func (s *tradeService) MatchOrder(ctx context.Context, req MatchOrderReq) (string, error) {
 	// 1. 校验订单状态：待成交、未过期
 	var order model.NFTOrder
-	if err := s.db.WithContext(ctx).Where("order_no = ? AND status = 0 AND end_time > ?", req.OrderNo, time.Now()).First(&order).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("order_no = ? AND status = ? AND end_time > ?", req.OrderNo, model.NFTOrderStatusPending, time.Now()).First(&order).Error; err != nil {
 		utils.Logger.Error("校验订单失败", zap.String("order_no", req.OrderNo), zap.Error(err))
 		return "", errors.New("订单不存在或已失效")
 	}
+	if order.StartTime.After(time.Now()) {
+		return "", errors.New("订单尚未开售")
+	}
 
 	// 2. 校验买家不能是卖家
-	if order.SellerAddr == req.BuyerAddr {
+	if strings.EqualFold(order.SellerAddr, req.BuyerAddr) {
 		return "", errors.New("不能购买自己的订单")
 	}
 
-	// 3. 更新订单状态为处理中，填充买家地址
-	if err := s.db.WithContext(ctx).Model(&order).Updates(map[string]interface{}{
-		"buyer_addr": req.BuyerAddr,
-		"status":     4, // 处理中
-	}).Error; err != nil {
+	// 拍卖订单需通过出价成交
+	if order.OrderType == 1 {
+		return "", errors.New("拍卖订单不支持直接购买，请出价")
+	}
+
+	// 签名挂单：校验卖家签名，且卖家未递增计数器（批量取消）
+	if order.Signature != "" {
+		if err := s.verifyOrderSignature(ctx, order); err != nil {
+			return "", err
+		}
+	}
+
+	// 私人挂单仅指定买家可购买
+	if order.ReservedBuyer != "" && !strings.EqualFold(order.ReservedBuyer, req.BuyerAddr) {
+		return "", errors.New("该订单为私人挂单，仅指定买家可购买")
+	}
+
+	// 成交价：荷兰式拍卖按购买时刻的当前价格成交，买家确认的价格为可接受的最高价；
+	// 一口价须与当前挂单价格一致（卖家可能已改价）
+	dealPrice := order.Price
+	if order.OrderType == 2 {
+		currentPrice := dutchCurrentPrice(order, time.Now())
+		if req.Price != "" {
+			maxPrice, ok := parseWei(req.Price)
+			if !ok {
+				return "", errors.New("价格格式错误")
+			}
+			if currentPrice.Cmp(maxPrice) > 0 {
+				return "", errors.New("当前价格高于确认的最高价格，请刷新后重试")
+			}
+		}
+		dealPrice = currentPrice.String()
+	} else if req.Price != "" && req.Price != order.Price {
+		return "", errors.New("订单价格已变更，请刷新后重试")
+	}
+
+	// ERC-1155挂单按数量部分成交，由子订单交割
+	if order.TokenStandard == model.TokenStandardERC1155 {
+		return s.matchERC1155Order(ctx, req, order)
+	}
+	if req.Quantity > 1 {
+		return "", errors.New("ERC-721订单购买数量只能为1")
+	}
+
+	// 3. 更新订单状态为处理中，填充买家地址，锁定成交价
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 条件更新：订单仍为待成交、未过期、未被卖家修改（版本号不变）且仍持有资产锁定（接受报价会转移锁定），
+	// 防止与并发改价/取消/过期/接受报价冲突
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  req.OrderNo,
+		From:     model.NFTOrderStatusPending,
+		To:       model.NFTOrderStatusProcessing,
+		Operator: req.BuyerAddr,
+		Reason:   "买家购买",
+		Updates: map[string]interface{}{
+			"buyer_addr": req.BuyerAddr,
+			"deal_price": dealPrice,
+		},
+		Where: "version = ? AND end_time > ? AND EXISTS (SELECT 1 FROM nft_asset_locks WHERE nft_asset_locks.order_no = nft_orders.order_no AND nft_asset_locks.unlock_time IS NULL)",
+		Args:  []interface{}{order.Version, time.Now()},
+	}); err != nil {
+		tx.Rollback()
 		utils.Logger.Error("更新订单状态失败", zap.String("order_no", req.OrderNo), zap.Error(err))
 		return "", err
 	}
 
+	tx.Commit()
+
 	// 4. 发布消息到RabbitMQ，异步执行交易
 	if err := utils.PublishTradeMsg(ctx, req.OrderNo); err != nil {
 		// 回滚订单状态
-		s.db.WithContext(ctx).Model(&order).Updates(map[string]interface{}{
-			"buyer_addr": "",
-			"status":     0,
+		transitionOrderTx(ctx, s.db, orderTransition{
+			OrderNo:  req.OrderNo,
+			From:     model.NFTOrderStatusProcessing,
+			To:       model.NFTOrderStatusPending,
+			Operator: operatorExecuteTrade,
+			Reason:   "发布交易消息失败，订单恢复待成交",
+			Updates: map[string]interface{}{
+				"buyer_addr": "",
+				"deal_price": "",
+			},
 		})
 		utils.Logger.Error("发布交易消息失败", zap.String("order_no", req.OrderNo), zap.Error(err))
 		return "", errors.New("发起交易失败，请稍后再试")
@@ -178,6 +389,181 @@ func (s *tradeService) MatchOrder(ctx context.Context, req MatchOrderReq) (strin
 	return req.OrderNo, nil
 }
 
+// CancelSellOrder 取消出售订单（卖家下架）
+func (s *tradeService) CancelSellOrder(ctx context.Context, req CancelSellOrderReq) error {
+	// 1. 查询订单并校验归属
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Where("order_no = ?", req.OrderNo).First(&order).Error; err != nil {
+		utils.Logger.Error("查询订单失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return errors.New("订单不存在")
+	}
+	if !strings.EqualFold(order.SellerAddr, req.SellerAddr) {
+		return errors.New("无权取消他人订单")
+	}
+
+	// 2. 校验订单状态：处理中/确认中的订单正在链上交割，不允许取消
+	if order.Status == model.NFTOrderStatusProcessing || order.Status == model.NFTOrderStatusConfirming {
+		return errors.New("订单正在处理中，无法取消")
+	}
+	if order.Status != model.NFTOrderStatusPending {
+		return &OrderTransitionError{OrderNo: req.OrderNo, From: model.NFTOrderStatusPending, To: model.NFTOrderStatusCancelled, Current: order.Status, Err: ErrOrderStateChanged}
+	}
+
+	// 已有出价的拍卖不允许取消：与出价共用拍卖锁，持锁完成出价检查与取消，防止检查后有新出价
+	if order.OrderType == 1 {
+		lockKey := fmt.Sprintf("nft_order_lock_%s", req.OrderNo)
+		mutex, err := utils.GetRedisLock(ctx, lockKey, 10*time.Second)
+		if err != nil {
+			utils.Logger.Error("获取分布式锁失败", zap.String("lockKey", lockKey), zap.Error(err))
+			return errors.New("当前拍卖出价繁忙，请稍后再试")
+		}
+		defer utils.ReleaseRedisLock(mutex)
+
+		var bidCount int64
+		if err := s.db.WithContext(ctx).Model(&model.NFTBid{}).Where("order_no = ? AND status = ?", req.OrderNo, model.BidStatusLeading).Count(&bidCount).Error; err != nil {
+			return err
+		}
+		if bidCount > 0 {
+			return errors.New("拍卖已有出价，无法取消")
+		}
+	}
+
+	// 3. 事务：更新订单状态 + 解锁资产（ERC-1155释放未售出数量）
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 条件更新：仅待成交状态可取消，防止与并发撮合冲突
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  req.OrderNo,
+		From:     model.NFTOrderStatusPending,
+		To:       model.NFTOrderStatusCancelled,
+		Operator: req.SellerAddr,
+		Reason:   "卖家取消",
+	}); err != nil {
+		tx.Rollback()
+		utils.Logger.Error("更新订单状态失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return err
+	}
+
+	// 解锁资产
+	if err := releaseOrderAssets(tx, req.OrderNo, req.SellerAddr); err != nil {
+		tx.Rollback()
+		utils.Logger.Error("解锁资产失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return err
+	}
+
+	tx.Commit()
+
+	utils.Logger.Info("订单已取消", zap.String("order_no", req.OrderNo), zap.String("seller_addr", req.SellerAddr))
+	return nil
+}
+
+// UpdateSellOrder 修改出售订单（无需取消重挂，保留订单号与资产锁定）
+func (s *tradeService) UpdateSellOrder(ctx context.Context, req UpdateSellOrderReq) error {
+	if req.Price == "" && req.EndTime == nil {
+		return errors.New("未指定修改内容")
+	}
+	if req.Price != "" {
+		if price, ok := parseWei(req.Price); !ok || price.Sign() <= 0 {
+			return errors.New("价格格式错误")
+		}
+	}
+	if req.EndTime != nil && !req.EndTime.After(time.Now()) {
+		return errors.New("结束时间必须晚于当前时间")
+	}
+
+	// 1. 查询订单并校验归属
+	var order model.NFTOrder
+	if err := s.db.WithContext(ctx).Where("order_no = ?", req.OrderNo).First(&order).Error; err != nil {
+		utils.Logger.Error("查询订单失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return errors.New("订单不存在")
+	}
+	if !strings.EqualFold(order.SellerAddr, req.SellerAddr) {
+		return errors.New("无权修改他人订单")
+	}
+
+	// 2. 校验订单状态：仅待成交的一口价订单可修改
+	if order.Status == model.NFTOrderStatusProcessing || order.Status == model.NFTOrderStatusConfirming {
+		return errors.New("订单正在处理中，无法修改")
+	}
+	if order.Status != model.NFTOrderStatusPending {
+		return errors.New("订单状态不允许修改")
+	}
+	if order.OrderType != 0 {
+		return errors.New("拍卖订单不支持修改价格")
+	}
+	if order.Signature != "" {
+		return errors.New("签名订单不支持修改，请取消后重新签名挂单")
+	}
+	// 结束时间还须晚于开售时间（定时挂单开售时间在未来）
+	if req.EndTime != nil && !req.EndTime.After(order.StartTime) {
+		return errors.New("结束时间必须晚于开售时间")
+	}
+
+	// 3. 事务：条件更新订单 + 记录改价历史
+	updates := map[string]interface{}{}
+	history := model.NFTOrderPriceHistory{
+		OrderNo:      req.OrderNo,
+		OldPrice:     order.Price,
+		NewPrice:     order.Price,
+		OldEndTime:   order.EndTime,
+		NewEndTime:   order.EndTime,
+		OperatorAddr: req.SellerAddr,
+	}
+	if req.Price != "" {
+		updates["price"] = req.Price
+		history.NewPrice = req.Price
+	}
+	if req.EndTime != nil {
+		updates["end_time"] = *req.EndTime
+		history.NewEndTime = *req.EndTime
+	}
+	updates["version"] = gorm.Expr("version + 1")
+
+	tx := s.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 条件更新：以读取时的版本号做乐观锁（每次修改递增），与并发撮合（同样以版本号为条件）互斥
+	result := tx.Model(&model.NFTOrder{}).Where("order_no = ? AND status = ? AND version = ?", req.OrderNo, model.NFTOrderStatusPending, order.Version).Updates(updates)
+	if result.Error != nil {
+		tx.Rollback()
+		utils.Logger.Error("修改订单失败", zap.String("order_no", req.OrderNo), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("订单状态已变更，请刷新后重试")
+	}
+
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		utils.Logger.Error("记录改价历史失败", zap.String("order_no", req.OrderNo), zap.Error(err))
+		return err
+	}
+
+	tx.Commit()
+
+	utils.Logger.Info("订单已修改", zap.String("order_no", req.OrderNo), zap.String("old_price", history.OldPrice), zap.String("new_price", history.NewPrice))
+	return nil
+}
+
+// GetPriceHistory 查询订单改价历史
+func (s *tradeService) GetPriceHistory(ctx context.Context, orderNo string) ([]model.NFTOrderPriceHistory, error) {
+	var histories []model.NFTOrderPriceHistory
+	if err := s.db.WithContext(ctx).Where("order_no = ?", orderNo).Order("id ASC").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
 // ExecuteTrade 执行交易（链上交割）
 func (s *tradeService) ExecuteTrade(ctx context.Context, orderNo string) error {
 	// 1. 查询订单信息
@@ -186,46 +572,84 @@ func (s *tradeService) ExecuteTrade(ctx context.Context, orderNo string) error {
 		utils.Logger.Error("查询订单失败", zap.String("order_no", orderNo), zap.Error(err))
 		return err
 	}
+	// 仅处理中的订单可交割（消息重复投递时跳过，避免重复转账）
+	if order.Status != model.NFTOrderStatusProcessing {
+		utils.Logger.Warn("订单状态非处理中，跳过交割", zap.String("order_no", orderNo), zap.Stringer("status", order.Status))
+		return nil
+	}
 
-	// 2. 查询NFT资产信息
-	var asset model.NFTAsset
-	if err := s.db.WithContext(ctx).Where("id = ?", order.NFTAssetID).First(&asset).Error; err != nil {
-		utils.Logger.Error("查询NFT资产失败", zap.Uint64("nft_asset_id", order.NFTAssetID), zap.Error(err))
+	// 2. 查询NFT资产信息（组合订单为全部明细）
+	assets, err := s.loadOrderAssets(ctx, order)
+	if err != nil {
+		utils.Logger.Error("查询NFT资产失败", zap.String("order_no", orderNo), zap.Error(err))
 		return err
 	}
 
 	// 3. 获取区块链RPC地址
+	// 链配置缺失无法通过重试恢复：订单置为失败并释放资产，避免消息无限重投
 	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[order.ChainID]
 	if !ok {
 		utils.Logger.Error("未配置链RPC地址", zap.Int("chain_id", order.ChainID))
-		return errors.New("链配置不存在")
+		if failErr := failOrder(ctx, s.db, orderNo, operatorExecuteTrade, "链配置不存在"); failErr != nil {
+			utils.Logger.Error("更新订单失败状态失败", zap.String("order_no", orderNo), zap.Error(failErr))
+			return failErr
+		}
+		return nil
 	}
 
-	// 4. 初始化ERC721合约交易器
-	transactor, err := contract.NewERC721Transactor(rpcUrl, order.ContractAddr)
+	// 4. 签名链上NFT转账交易（卖家→买家）
+	// 由市场操作员账户发起转账，卖家挂单时已对操作员（组合订单为批量转账合约）执行setApprovalForAll授权
+	operator, err := getOperatorSigner(order.ChainID)
+	if err != nil {
+		utils.Logger.Error("未配置市场操作员账户", zap.Int("chain_id", order.ChainID))
+		if failErr := failOrder(ctx, s.db, orderNo, operatorExecuteTrade, "市场操作员账户未配置"); failErr != nil {
+			utils.Logger.Error("更新订单失败状态失败", zap.String("order_no", orderNo), zap.Error(failErr))
+			return failErr
+		}
+		return nil
+	}
+	tracker, err := getTxTracker(order.ChainID)
 	if err != nil {
 		return err
 	}
-
-	// 5. 执行链上NFT转账（卖家→买家）
-	// 注意：生产环境中，私钥不应直接存储，需通过钱包签名获取交易哈希
-	// 此处为演示，假设从配置/钱包服务中获取卖家私钥
-	sellerPrivateKey := "0x你的卖家私钥" // 替换为实际私钥（测试网）
-	txHash, err := transactor.SafeTransferFrom(sellerPrivateKey, order.SellerAddr, order.BuyerAddr, order.TokenID)
+	// 同一操作员账户从签名（分配nonce）到广播串行执行，避免多实例并发发送分配到相同nonce
+	lockKey := fmt.Sprintf("nft_operator_send_%d", order.ChainID)
+	mutex, err := utils.GetRedisLock(ctx, lockKey, operatorSendLockTTL)
 	if err != nil {
-		// 更新订单状态为失败
-		s.db.WithContext(ctx).Model(&order).Update("status", 5)
+		utils.Logger.Error("获取操作员发送锁失败", zap.String("lockKey", lockKey), zap.Error(err))
 		return err
 	}
+	defer utils.ReleaseRedisLock(mutex)
+	chainCtx, cancel := context.WithTimeout(ctx, settlementSendTimeout)
+	defer cancel()
+	signedTx, err := s.signOrderTransfer(chainCtx, rpcUrl, operator, order, assets)
+	if err != nil {
+		// 更新订单状态为失败，释放资产锁定
+		if failErr := failOrder(ctx, s.db, orderNo, operatorExecuteTrade, "链上转账失败："+err.Error()); failErr != nil {
+			utils.Logger.Error("更新订单失败状态失败", zap.String("order_no", orderNo), zap.Error(failErr))
+		}
+		return err
+	}
+	rawTx, err := contract.EncodeRawTx(signedTx)
+	if err != nil {
+		return err
+	}
+	txHash := signedTx.Hash().Hex()
 
-	// 6. 计算平台手续费
+	// 5. 计算平台手续费（拍卖等以成交价计算）
+	tradePrice := order.Price
+	if order.DealPrice != "" {
+		tradePrice = order.DealPrice
+	}
 	feeRate := config.GlobalConfig.PlatformFeeRate
-	priceBig, _ := new(big.Float).SetString(order.Price)
+	priceBig, _ := new(big.Float).SetString(tradePrice)
 	feeBig := new(big.Float).Mul(priceBig, big.NewFloat(feeRate))
 	fee := feeBig.Text('f', 0) // 手续费（wei单位）
 	feeAddr := config.GlobalConfig.PlatformFeeAddr
 
-	// 7. 事务：更新订单状态 + 解锁资产 + 更新NFT所有者 + 创建交易记录
+	// 6. 事务：订单置为确认中 + 创建交易记录（确认中，含原始交易）
+	// 先持久化原始交易再广播：交易丢失时由ConfirmWorker原样重新广播，不会以新nonce重复转账；
+	// 资产解锁与所有者变更在达到确认区块数后由ConfirmWorker完成，期间链重组不会造成账实不符
 	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -233,49 +657,225 @@ func (s *tradeService) ExecuteTrade(ctx context.Context, orderNo string) error {
 		}
 	}()
 
-	// 更新订单状态为已成交
-	if err := tx.Model(&order).Update("status", 1).Error; err != nil {
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  orderNo,
+		From:     model.NFTOrderStatusProcessing,
+		To:       model.NFTOrderStatusConfirming,
+		Operator: operatorExecuteTrade,
+		Reason:   "交割交易已签名广播，等待区块确认，交易哈希：" + txHash,
+	}); err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 解锁资产
-	unlockTime := time.Now()
-	if err := tx.Model(&model.NFTAssetLock{}).Where("order_no = ?", orderNo).Update("unlock_time", &unlockTime).Error; err != nil {
+	// 创建交易记录
+	tradeNo := uuid.NewString()
+	tradeRecord := model.NFTTradeRecord{
+		TradeNo:       tradeNo,
+		OrderNo:       orderNo,
+		NFTAssetID:    order.NFTAssetID,
+		IsBundle:      order.IsBundle,
+		ItemCount:     len(assets),
+		Quantity:      order.Quantity,
+		SellerAddr:    order.SellerAddr,
+		BuyerAddr:     order.BuyerAddr,
+		Price:         tradePrice,
+		Fee:           fee,
+		FeeAddr:       feeAddr,
+		TxHash:        txHash,
+		TxNonce:       signedTx.Nonce(),
+		RawTx:         rawTx,
+		ChainID:       order.ChainID,
+		ConfirmStatus: model.TradeConfirmStatusConfirming,
+		TradeTime:     time.Now(),
+	}
+	if err := tx.Create(&tradeRecord).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 更新NFT资产所有者
-	if err := tx.Model(&asset).Update("owner_addr", order.BuyerAddr).Error; err != nil {
-		tx.Rollback()
+	// 组合交易记录明细
+	if order.IsBundle {
+		recordItems := make([]model.NFTTradeRecordItem, 0, len(assets))
+		for _, asset := range assets {
+			recordItems = append(recordItems, model.NFTTradeRecordItem{
+				TradeNo:      tradeNo,
+				NFTAssetID:   asset.ID,
+				TokenID:      asset.TokenID,
+				ContractAddr: asset.ContractAddr,
+			})
+		}
+		if err := tx.Create(&recordItems).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		utils.Logger.Error("保存交割交易失败", zap.String("order_no", orderNo), zap.Error(err))
 		return err
 	}
 
-	// 创建交易记录
-	tradeNo := uuid.NewString()
-	tradeRecord := model.NFTTradeRecord{
-		TradeNo:    tradeNo,
-		OrderNo:    orderNo,
-		NFTAssetID: order.NFTAssetID,
-		SellerAddr: order.SellerAddr,
-		BuyerAddr:  order.BuyerAddr,
-		Price:      order.Price,
-		Fee:        fee,
-		FeeAddr:    feeAddr,
-		TxHash:     txHash,
-		ChainID:    order.ChainID,
-		TradeTime:  time.Now(),
+	// 7. 广播交易（失败仅记录日志，由ConfirmWorker原样重新广播）
+	if err := tracker.SendTransaction(chainCtx, signedTx); err != nil {
+		utils.Logger.Warn("广播交割交易失败，等待重新广播", zap.String("order_no", orderNo), zap.String("tx_hash", txHash), zap.Error(err))
 	}
-	if err := tx.Create(&tradeRecord).Error; err != nil {
-		tx.Rollback()
+
+	utils.Logger.Info("交割交易已广播，等待确认", zap.String("order_no", orderNo), zap.String("trade_no", tradeNo), zap.String("tx_hash", txHash), zap.Uint64("nonce", signedTx.Nonce()))
+	return nil
+}
+
+// signOrderTransfer 由市场操作员签名订单资产的链上转账交易（不广播）
+func (s *tradeService) signOrderTransfer(ctx context.Context, rpcUrl string, operator contract.Signer, order model.NFTOrder, assets []model.NFTAsset) (*types.Transaction, error) {
+	if order.IsBundle {
+		// 组合订单：通过批量转账辅助合约在一笔交易内转移全部NFT，任一失败整笔回滚
+		return s.signBundleTransfer(ctx, rpcUrl, operator, order, assets)
+	}
+	if order.TokenStandard == model.TokenStandardERC1155 {
+		// ERC-1155按子订单数量转账
+		transactor, err := contract.NewERC1155Transactor(rpcUrl, order.ContractAddr)
+		if err != nil {
+			return nil, err
+		}
+		defer transactor.Close()
+		return transactor.SignSafeTransferFrom(ctx, operator, order.SellerAddr, order.BuyerAddr, order.TokenID, order.Quantity, nil)
+	}
+	// 初始化ERC721合约交易器
+	transactor, err := contract.NewERC721Transactor(rpcUrl, order.ContractAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer transactor.Close()
+	return transactor.SignSafeTransferFrom(ctx, operator, order.SellerAddr, order.BuyerAddr, order.TokenID)
+}
+
+// finalizeTrade 交割交易达到确认区块数：订单置为已成交，解锁资产并更新所有者（ERC-1155更新双方持有量），交易记录置为已确认（需在事务内调用）
+func finalizeTrade(tx *gorm.DB, order model.NFTOrder, assets []model.NFTAsset, record model.NFTTradeRecord) error {
+	// 更新订单状态为已成交
+	if err := transitionOrder(tx, orderTransition{
+		OrderNo:  order.OrderNo,
+		From:     model.NFTOrderStatusConfirming,
+		To:       model.NFTOrderStatusCompleted,
+		Operator: operatorConfirmWorker,
+		Reason:   fmt.Sprintf("链上交割已确认，交易哈希：%s，区块高度：%d", record.TxHash, record.BlockNumber),
+	}); err != nil {
 		return err
 	}
 
-	tx.Commit()
+	if order.TokenStandard == model.TokenStandardERC1155 {
+		// 更新双方持有量
+		if err := settleERC1155Trade(tx, order); err != nil {
+			return err
+		}
+	} else {
+		// 解锁资产
+		unlockTime := time.Now()
+		if err := tx.Model(&model.NFTAssetLock{}).Where("order_no = ?", order.OrderNo).Update("unlock_time", &unlockTime).Error; err != nil {
+			return err
+		}
 
-	utils.Logger.Info("交易执行成功", zap.String("order_no", orderNo), zap.String("trade_no", tradeNo), zap.String("tx_hash", txHash))
-	return nil
+		// 更新NFT资产所有者
+		assetIDs := make([]uint64, 0, len(assets))
+		for _, asset := range assets {
+			assetIDs = append(assetIDs, asset.ID)
+		}
+		if err := tx.Model(&model.NFTAsset{}).Where("id IN ?", assetIDs).Update("owner_addr", order.BuyerAddr).Error; err != nil {
+			return err
+		}
+	}
+
+	// 交易记录置为已确认
+	return tx.Model(&model.NFTTradeRecord{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"confirm_status": model.TradeConfirmStatusConfirmed,
+		"trade_time":     time.Now(),
+	}).Error
+}
+
+// signBundleTransfer 签名组合订单链上交割交易
+func (s *tradeService) signBundleTransfer(ctx context.Context, rpcUrl string, operator contract.Signer, order model.NFTOrder, assets []model.NFTAsset) (*types.Transaction, error) {
+	batchAddr := config.GlobalConfig.BatchTransferAddr[order.ChainID]
+	if batchAddr == "" {
+		utils.Logger.Error("未配置批量转账合约地址", zap.Int("chain_id", order.ChainID))
+		return nil, errors.New("批量转账合约配置不存在")
+	}
+	transactor, err := contract.NewBatchTransferTransactor(rpcUrl, batchAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer transactor.Close()
+
+	tokens := make([]string, 0, len(assets))
+	tokenIds := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		tokens = append(tokens, asset.ContractAddr)
+		tokenIds = append(tokenIds, asset.TokenID)
+	}
+	return transactor.SignBatchTransferFrom(ctx, operator, tokens, tokenIds, order.SellerAddr, order.BuyerAddr)
+}
+
+// validateSellOrderReq 校验出售订单的类型与价格参数，返回资产锁定类型
+func validateSellOrderReq(req CreateSellOrderReq) (int, error) {
+	if _, ok := parseWei(req.Price); !ok || req.Price == "" {
+		return 0, errors.New("价格格式错误")
+	}
+	if req.StartTime != nil && req.EndTime != nil && !req.EndTime.After(*req.StartTime) {
+		return 0, errors.New("结束时间必须晚于开售时间")
+	}
+	if req.ReservedBuyer != "" {
+		if req.OrderType != 0 {
+			return 0, errors.New("私人挂单仅支持一口价")
+		}
+		if !common.IsHexAddress(req.ReservedBuyer) {
+			return 0, errors.New("指定买家地址格式错误")
+		}
+		if strings.EqualFold(req.ReservedBuyer, req.SellerAddr) {
+			return 0, errors.New("指定买家不能是卖家本人")
+		}
+	}
+	switch req.OrderType {
+	case 0:
+		return 0, nil // 交易挂单
+	case 1:
+		if err := validateAuctionParams(req); err != nil {
+			return 0, err
+		}
+		return 1, nil // 拍卖
+	case 2:
+		if err := validateDutchParams(req); err != nil {
+			return 0, err
+		}
+		return 1, nil // 拍卖
+	default:
+		return 0, errors.New("暂不支持的订单类型")
+	}
+}
+
+// lockAsset 锁定NFT资产
+// nft_asset_id为唯一索引，资产解锁后再次挂单时复用原锁定记录
+func lockAsset(tx *gorm.DB, nftAssetID uint64, orderNo string, lockType int) error {
+	var lockRecord model.NFTAssetLock
+	err := tx.Where("nft_asset_id = ?", nftAssetID).First(&lockRecord).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&model.NFTAssetLock{
+			NFTAssetID: nftAssetID,
+			OrderNo:    orderNo,
+			LockType:   lockType,
+			LockTime:   time.Now(),
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	if lockRecord.UnlockTime == nil {
+		return errors.New("NFT资产已被锁定，无法挂单")
+	}
+
+	return tx.Model(&lockRecord).Updates(map[string]interface{}{
+		"order_no":    orderNo,
+		"lock_type":   lockType,
+		"lock_time":   time.Now(),
+		"unlock_time": nil,
+	}).Error
 }
 
 // GetTradeRecords 查询交易记录
@@ -289,7 +889,9 @@ func (s *tradeService) GetTradeRecords(ctx context.Context, req GetTradeRecordsR
 		query = query.Where("seller_addr = ? OR buyer_addr = ?", req.UserAddr, req.UserAddr)
 	}
 	if req.NFTAssetID > 0 {
-		query = query.Where("nft_asset_id = ?", req.NFTAssetID)
+		// 包含该NFT参与的组合交易
+		query = query.Where("nft_asset_id = ? OR trade_no IN (?)", req.NFTAssetID,
+			s.db.Model(&model.NFTTradeRecordItem{}).Select("trade_no").Where("nft_asset_id = ?", req.NFTAssetID))
 	}
 
 	// 统计总数
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"nft_trade/config"
+	"nft_trade/contract"
+	"nft_trade/model"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TransferIndexer ERC-721 Transfer事件索引任务
+// 按链从持久化的区块进度开始扫描已上架合约的Transfer事件，同步NFT持有者、标记销毁，
+// 并下架卖家已不再持有该NFT的待成交订单、释放资产锁定
+type TransferIndexer struct {
+	db          *gorm.DB
+	interval    time.Duration
+	batchBlocks uint64
+
+	mu      sync.Mutex
+	filters map[int]*contract.ERC721LogFilter
+}
+
+// invalidatedOrder 因链上转移被下架的订单（事务提交后发布事件）
+type invalidatedOrder struct {
+	order    model.NFTOrder
+	transfer contract.ERC721Transfer
+}
+
+// NewTransferIndexer 创建Transfer事件索引任务
+func NewTransferIndexer(db *gorm.DB, interval time.Duration, batchBlocks uint64) *TransferIndexer {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	if batchBlocks == 0 {
+		batchBlocks = 2000
+	}
+	return &TransferIndexer{
+		db:          db,
+		interval:    interval,
+		batchBlocks: batchBlocks,
+		filters:     make(map[int]*contract.ERC721LogFilter),
+	}
+}
+
+// SetLogFilter 指定链使用的事件查询器（如go-ethereum模拟链后端）
+func (w *TransferIndexer) SetLogFilter(chainID int, filter *contract.ERC721LogFilter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filters[chainID] = filter
+}
+
+// Start 启动索引任务（阻塞，直到ctx取消）
+func (w *TransferIndexer) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.Logger.Info("Transfer事件索引任务已停止")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce 执行一轮索引
+func (w *TransferIndexer) runOnce(ctx context.Context) {
+	// 分布式锁：多实例部署时仅一个实例执行索引；
+	// 锁有效期为扫描间隔的4倍，本轮处理在锁到期前一个间隔超时退出，锁不会在处理中途过期（未完成的区块下一轮继续）
+	lockTTL := 4 * w.interval
+	mutex, err := utils.TryRedisLock(ctx, "nft_transfer_indexer", lockTTL)
+	if err != nil {
+		return
+	}
+	defer utils.ReleaseRedisLock(mutex)
+	ctx, cancel := context.WithTimeout(ctx, lockTTL-w.interval)
+	defer cancel()
+
+	for chainID := range config.GlobalConfig.ChainRPCUrl {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := w.indexChain(ctx, chainID); err != nil && ctx.Err() == nil {
+			utils.Logger.Error("索引Transfer事件失败", zap.Int("chain_id", chainID), zap.Error(err))
+		}
+	}
+}
+
+// indexChain 索引单条链：从进度区块扫描至已确认区块（最新区块减确认数）
+func (w *TransferIndexer) indexChain(ctx context.Context, chainID int) error {
+	filter, err := w.getLogFilter(chainID)
+	if err != nil {
+		return err
+	}
+
+	// 1. 计算已确认区块高度（未达到确认数的区块可能被回滚，暂不索引）
+	head, err := filter.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	depth := config.GlobalConfig.ConfirmationDepth[chainID]
+	if head < depth {
+		return nil
+	}
+	safeHead := head - depth
+
+	// 2. 读取索引进度，首次索引从配置的起始区块（未配置则从当前已确认区块）开始
+	var checkpoint model.NFTIndexerCheckpoint
+	err = w.db.WithContext(ctx).Where("chain_id = ?", chainID).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		checkpoint = model.NFTIndexerCheckpoint{ChainID: chainID, LastBlock: safeHead}
+		if start := config.GlobalConfig.IndexerStartBlock[chainID]; start > 0 {
+			checkpoint.LastBlock = start - 1
+		}
+		if err := w.db.WithContext(ctx).Create(&checkpoint).Error; err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// 3. 分批扫描，每批的状态同步与进度推进在同一事务内提交
+	for checkpoint.LastBlock < safeHead {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fromBlock := checkpoint.LastBlock + 1
+		toBlock := min(fromBlock+w.batchBlocks-1, safeHead)
+
+		contracts, err := w.trackedContracts(ctx, chainID)
+		if err != nil {
+			return err
+		}
+		var transfers []contract.ERC721Transfer
+		if len(contracts) > 0 {
+			transfers, err = filter.FilterTransfers(ctx, contracts, fromBlock, toBlock)
+			if err != nil {
+				return err
+			}
+		}
+
+		var invalidated []invalidatedOrder
+		err = w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, transfer := range transfers {
+				orders, err := applyTransfer(tx, chainID, transfer)
+				if err != nil {
+					return err
+				}
+				invalidated = append(invalidated, orders...)
+			}
+			// 条件更新：进度未被其他实例推进
+			result := tx.Model(&model.NFTIndexerCheckpoint{}).
+				Where("chain_id = ? AND last_block = ?", chainID, checkpoint.LastBlock).
+				Update("last_block", toBlock)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("索引进度已变更")
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		checkpoint.LastBlock = toBlock
+
+		// 发布订单下架事件（失败仅记录日志，订单状态已落库）
+		for _, item := range invalidated {
+			if err := utils.PublishOrderEvent(ctx, "order.invalidated", item.order.OrderNo, map[string]interface{}{
+				"nft_asset_id": item.order.NFTAssetID,
+				"seller_addr":  item.order.SellerAddr,
+				"owner_addr":   item.transfer.To.Hex(),
+				"tx_hash":      item.transfer.TxHash.Hex(),
+			}); err != nil {
+				utils.Logger.Error("发布订单下架事件失败", zap.String("order_no", item.order.OrderNo), zap.Error(err))
+			}
+		}
+		utils.Logger.Info("Transfer事件索引完成", zap.Int("chain_id", chainID), zap.Uint64("from_block", fromBlock), zap.Uint64("to_block", toBlock),
+			zap.Int("transfers", len(transfers)), zap.Int("invalidated_orders", len(invalidated)))
+	}
+	return nil
+}
+
+// trackedContracts 查询链上需要索引的ERC-721合约（平台已收录资产的合约）
+func (w *TransferIndexer) trackedContracts(ctx context.Context, chainID int) ([]common.Address, error) {
+	var addrs []string
+	if err := w.db.WithContext(ctx).Model(&model.NFTAsset{}).
+		Where("chain_id = ? AND token_standard = ? AND contract_addr <> ''", chainID, model.TokenStandardERC721).
+		Distinct().Pluck("contract_addr", &addrs).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[common.Address]bool, len(addrs))
+	contracts := make([]common.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		if !common.IsHexAddress(addr) {
+			continue
+		}
+		contractAddr := common.HexToAddress(addr)
+		if !seen[contractAddr] {
+			seen[contractAddr] = true
+			contracts = append(contracts, contractAddr)
+		}
+	}
+	return contracts, nil
+}
+
+// getLogFilter 获取链对应的事件查询器
+func (w *TransferIndexer) getLogFilter(chainID int) (*contract.ERC721LogFilter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if filter, ok := w.filters[chainID]; ok {
+		return filter, nil
+	}
+	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[chainID]
+	if !ok {
+		return nil, errors.New("链配置不存在")
+	}
+	filter, err := contract.DialERC721LogFilter(rpcUrl)
+	if err != nil {
+		return nil, err
+	}
+	w.filters[chainID] = filter
+	return filter, nil
+}
+
+// applyTransfer 同步单次转移：更新持有者或标记销毁，下架卖家不再持有该NFT的待成交订单（需在事务内调用）
+func applyTransfer(tx *gorm.DB, chainID int, transfer contract.ERC721Transfer) ([]invalidatedOrder, error) {
+	// 1. 查询平台收录的资产（未收录的Token忽略）
+	var asset model.NFTAsset
+	err := tx.Where("chain_id = ? AND LOWER(contract_addr) = ? AND token_id = ? AND token_standard = ?",
+		chainID, strings.ToLower(transfer.ContractAddr.Hex()), transfer.TokenID.String(), model.TokenStandardERC721).First(&asset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 更新持有者，转移至零地址视为销毁
+	burned := transfer.To == (common.Address{})
+	newOwner := transfer.To.Hex()
+	if burned {
+		if err := tx.Model(&asset).Update("status", 1).Error; err != nil {
+			return nil, err
+		}
+	} else if !strings.EqualFold(asset.OwnerAddr, newOwner) {
+		if err := tx.Model(&asset).Update("owner_addr", newOwner).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. 下架卖家已不再持有该NFT的待成交订单（含包含该NFT的组合订单），处理中/确认中的订单由交割流程处理
+	var orders []model.NFTOrder
+	if err := tx.Where("status = ? AND (nft_asset_id = ? OR order_no IN (?))", model.NFTOrderStatusPending, asset.ID,
+		tx.Model(&model.NFTOrderItem{}).Select("order_no").Where("nft_asset_id = ?", asset.ID)).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	reason := fmt.Sprintf("卖家已不再持有该NFT（链上转移，交易哈希：%s）", transfer.TxHash.Hex())
+	if burned {
+		reason = fmt.Sprintf("NFT已销毁（交易哈希：%s）", transfer.TxHash.Hex())
+	}
+	var invalidated []invalidatedOrder
+	for _, order := range orders {
+		if !burned && strings.EqualFold(order.SellerAddr, newOwner) {
+			continue
+		}
+		if err := transitionOrder(tx, orderTransition{
+			OrderNo:  order.OrderNo,
+			From:     model.NFTOrderStatusPending,
+			To:       model.NFTOrderStatusCancelled,
+			Operator: operatorTransferIndexer,
+			Reason:   reason,
+		}); err != nil {
+			if errors.Is(err, ErrOrderStateChanged) {
+				continue
+			}
+			return nil, err
+		}
+		if err := releaseOrderAssets(tx, order.OrderNo, operatorTransferIndexer); err != nil {
+			return nil, err
+		}
+		// 释放拍卖领先出价
+		if err := tx.Model(&model.NFTBid{}).Where("order_no = ? AND status = ?", order.OrderNo, model.BidStatusLeading).Update("status", model.BidStatusCancelled).Error; err != nil {
+			return nil, err
+		}
+		invalidated = append(invalidated, invalidatedOrder{order: order, transfer: transfer})
+	}
+
+	// 4. 释放关联订单已终止的残留锁定
+	unlockTime := time.Now()
+	if err := tx.Model(&model.NFTAssetLock{}).
+		Where("nft_asset_id = ? AND unlock_time IS NULL AND order_no NOT IN (?)", asset.ID,
+			tx.Model(&model.NFTOrder{}).Select("order_no").Where("status IN ?", []model.NFTOrderStatus{model.NFTOrderStatusPending, model.NFTOrderStatusProcessing, model.NFTOrderStatusConfirming})).
+		Update("unlock_time", &unlockTime).Error; err != nil {
+		return nil, err
+	}
+	return invalidated, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"nft_trade/config"
+	"nft_trade/contract"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.uber.org/zap"
+)
+
+// erc1271Callers 链ID -> 合约钱包签名校验器（首次使用时按配置的RPC地址连接）
+var (
+	erc1271Mu      sync.Mutex
+	erc1271Callers = make(map[int]*contract.ERC1271Caller)
+)
+
+// SetERC1271Caller 指定链使用的合约钱包签名校验器（如go-ethereum模拟链后端）
+func SetERC1271Caller(chainID int, caller *contract.ERC1271Caller) {
+	erc1271Mu.Lock()
+	defer erc1271Mu.Unlock()
+	erc1271Callers[chainID] = caller
+}
+
+// getERC1271Caller 获取链对应的签名校验器，未配置RPC的链返回nil
+func getERC1271Caller(chainID int) (*contract.ERC1271Caller, error) {
+	erc1271Mu.Lock()
+	defer erc1271Mu.Unlock()
+	if caller, ok := erc1271Callers[chainID]; ok {
+		return caller, nil
+	}
+	rpcUrl, ok := config.GlobalConfig.ChainRPCUrl[chainID]
+	if !ok {
+		return nil, nil
+	}
+	caller, err := contract.DialERC1271Caller(rpcUrl)
+	if err != nil {
+		return nil, err
+	}
+	erc1271Callers[chainID] = caller
+	return caller, nil
+}
+
+// verifyMessageSignature 校验personal_sign（EIP-191）签名，支持EOA与合约钱包
+func verifyMessageSignature(ctx context.Context, chainID int, signerAddr, message, signature string) error {
+	return verifyWalletSignature(ctx, chainID, signerAddr, accounts.TextHash([]byte(message)), signature)
+}
+
+// verifyTypedDataSignature 校验EIP-712结构化签名，支持EOA与合约钱包
+func verifyTypedDataSignature(ctx context.Context, chainID int, signerAddr string, typedData apitypes.TypedData, signature string) error {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	return verifyWalletSignature(ctx, chainID, signerAddr, hash, signature)
+}
+
+// verifyWalletSignature 校验待签哈希的签名
+// 先按ECDSA恢复签名者；不匹配时若签名地址部署了合约代码（Safe多签等合约钱包），
+// 则在该链上调用ERC-1271 isValidSignature，结果短暂缓存在Redis（节点故障等错误不缓存）
+func verifyWalletSignature(ctx context.Context, chainID int, signerAddr string, hash []byte, signature string) error {
+	if !common.IsHexAddress(signerAddr) {
+		return ErrInvalidSignature
+	}
+	if signer, err := utils.RecoverHashSigner(hash, signature); err == nil && strings.EqualFold(signer.Hex(), signerAddr) {
+		return nil
+	}
+
+	sig, err := utils.DecodeSignature(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	// 1. 查询缓存（Redis未初始化时直接链上校验）
+	digest := sha256.Sum256(append(append([]byte{}, hash...), sig...))
+	key := fmt.Sprintf("nft_erc1271_%d_%s_%s", chainID, strings.ToLower(signerAddr), hex.EncodeToString(digest[:]))
+	if utils.RedisClient != nil {
+		if cached, err := utils.RedisClient.Get(ctx, key).Result(); err == nil {
+			if cached == "1" {
+				return nil
+			}
+			return ErrInvalidSignature
+		}
+	}
+
+	// 2. 合约钱包校验
+	valid, err := verifyERC1271Signature(ctx, chainID, common.HexToAddress(signerAddr), common.BytesToHash(hash), sig)
+	if err != nil {
+		return err
+	}
+	if utils.RedisClient != nil {
+		cached := "0"
+		if valid {
+			cached = "1"
+		}
+		if err := utils.RedisClient.Set(ctx, key, cached, config.GlobalConfig.ERC1271CacheTTL).Err(); err != nil {
+			utils.Logger.Warn("缓存合约钱包签名校验结果失败", zap.String("signer_addr", signerAddr), zap.Error(err))
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// verifyERC1271Signature 签名地址为合约时调用isValidSignature，非合约地址视为签名无效
+func verifyERC1271Signature(ctx context.Context, chainID int, wallet common.Address, hash common.Hash, sig []byte) (bool, error) {
+	caller, err := getERC1271Caller(chainID)
+	if err != nil {
+		return false, err
+	}
+	if caller == nil {
+		return false, nil
+	}
+	hasCode, err := caller.HasCode(ctx, wallet)
+	if err != nil {
+		utils.Logger.Error("查询合约代码失败", zap.Int("chain_id", chainID), zap.String("wallet", wallet.Hex()), zap.Error(err))
+		return false, err
+	}
+	if !hasCode {
+		return false, nil
+	}
+	return caller.IsValidSignature(ctx, wallet, hash, sig)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"nft_trade/contract"
+	"nft_trade/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// simulatedChainID go-ethereum模拟链的链ID
+const simulatedChainID = 1337
+
+// erc1271WalletCode 最小ERC-1271合约钱包的部署字节码：
+// isValidSignature(hash, signature)在hash等于approved时返回魔术值，否则回滚（不校验signature内容）
+func erc1271WalletCode(approved common.Hash) []byte {
+	runtime := []byte{
+		0x60, 0x04, 0x35, // PUSH1 4 CALLDATALOAD：读取hash参数
+		0x7f, // PUSH32 approved
+	}
+	runtime = append(runtime, approved.Bytes()...)
+	runtime = append(runtime,
+		0x14,       // EQ
+		0x60, 0x2d, // PUSH1 valid
+		0x57,                         // JUMPI
+		0x60, 0x00, 0x60, 0x00, 0xfd, // REVERT(0, 0)
+		0x5b,                               // valid: JUMPDEST
+		0x63, 0x16, 0x26, 0xba, 0x7e, 0x60, // PUSH4 magicValue PUSH1 224
+		0xe0, 0x1b, 0x60, 0x00, 0x52, // SHL PUSH1 0 MSTORE
+		0x60, 0x20, 0x60, 0x00, 0xf3, // RETURN(0, 32)
+	)
+	// 部署代码：CODECOPY运行时代码并返回
+	initCode := []byte{0x60, byte(len(runtime)), 0x80, 0x60, 0x0b, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(initCode, runtime...)
+}
+
+// deployERC1271Wallet 在模拟链上部署合约钱包，返回钱包地址
+func deployERC1271Wallet(t *testing.T, sim *backends.SimulatedBackend, deployer []byte, approved common.Hash) common.Address {
+	t.Helper()
+	ctx := context.Background()
+	key, err := crypto.ToECDSA(deployer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := sim.PendingNonceAt(ctx, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gasPrice, err := sim.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignTx(types.NewContractCreation(nonce, big.NewInt(0), 200000, gasPrice, erc1271WalletCode(approved)),
+		types.LatestSignerForChainID(big.NewInt(simulatedChainID)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	return crypto.CreateAddress(from, nonce)
+}
+
+func TestVerifyMessageSignatureERC1271(t *testing.T) {
+	if err := utils.InitLogger(); err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := backends.NewSimulatedBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+	}, 30_000_000)
+	defer sim.Close()
+
+	message := "NFT Trade: cancel sell order\norder_no: 1"
+	wallet := deployERC1271Wallet(t, sim, crypto.FromECDSA(key), common.BytesToHash(accounts.TextHash([]byte(message))))
+
+	caller, err := contract.NewERC1271Caller(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetERC1271Caller(simulatedChainID, caller)
+
+	// 合约钱包签名长度不固定，不满足ECDSA格式时直接走ERC-1271校验
+	if err := verifyMessageSignature(context.Background(), simulatedChainID, wallet.Hex(), message, "0x01"); err != nil {
+		t.Fatalf("有效签名校验失败：%v", err)
+	}
+	err = verifyMessageSignature(context.Background(), simulatedChainID, wallet.Hex(), message+"\ntampered", "0x01")
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("无效签名应返回ErrInvalidSignature，实际：%v", err)
+	}
+}
+
+// rpcError 模拟节点返回的非回滚RPC错误（如限流）
+type rpcError struct{}
+
+func (rpcError) Error() string  { return "rate limit exceeded" }
+func (rpcError) ErrorCode() int { return -32005 }
+
+// failingCaller isValidSignature调用总是返回RPC错误的只读后端
+type failingCaller struct{}
+
+func (failingCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (failingCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, rpcError{}
+}
+
+func TestVerifyMessageSignatureRPCError(t *testing.T) {
+	if err := utils.InitLogger(); err != nil {
+		t.Fatal(err)
+	}
+	const chainID = 1338
+	caller, err := contract.NewERC1271Caller(failingCaller{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetERC1271Caller(chainID, caller)
+
+	// 节点错误须原样返回（不视为签名无效，不缓存）
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	err = verifyMessageSignature(context.Background(), chainID, wallet.Hex(), "message", "0x01")
+	if err == nil || errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("节点错误应原样返回，实际：%v", err)
+	}
+}
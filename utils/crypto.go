@@ -1,15 +1,118 @@
 package utils
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
-// VerifySignature 验证签名（简化版：实际需用ECDSA验证钱包签名）
-// params: userAddr-用户地址, data-待签数据, signature-签名
+// VerifySignature 验证钱包签名（EIP-191 personal_sign）
+// params: userAddr-用户地址, data-待签数据（原文）, signature-签名（65字节十六进制，可带0x前缀）
 func VerifySignature(userAddr, data, signature string) bool {
-	// 模拟验签：实际需调用go-ethereum的crypto包验证
-	hash := sha256.Sum256([]byte(data + userAddr))
-	expectedSig := hex.EncodeToString(hash[:])
-	return signature == expectedSig[:16] // 简化匹配
+	signer, err := RecoverSigner(data, signature)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(signer.Hex(), userAddr)
+}
+
+// RecoverSigner 从EIP-191 personal_sign签名中恢复签名者地址
+// 待签哈希为 keccak256("\x19Ethereum Signed Message:\n" + len(data) + data)
+func RecoverSigner(data, signature string) (common.Address, error) {
+	return RecoverHashSigner(accounts.TextHash([]byte(data)), signature)
+}
+
+// VerifyTypedDataSignature 验证EIP-712结构化数据签名（eth_signTypedData_v4）
+func VerifyTypedDataSignature(userAddr string, typedData apitypes.TypedData, signature string) bool {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return false
+	}
+	signer, err := RecoverHashSigner(hash, signature)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(signer.Hex(), userAddr)
+}
+
+// RecoverHashSigner 从签名中恢复待签哈希的签名者地址
+func RecoverHashSigner(hash []byte, signature string) (common.Address, error) {
+	sig, err := DecodeSignature(signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("签名长度错误")
+	}
+	// 钱包签名的V值为27/28，go-ethereum要求0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// DecodeSignature 解码十六进制签名（可带0x前缀），不校验长度（合约钱包签名长度不固定）
+func DecodeSignature(signature string) ([]byte, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(signature, "0x"), "0X"))
+	if err != nil || len(sig) == 0 {
+		return nil, errors.New("签名格式错误")
+	}
+	return sig, nil
+}
+
+// EncryptSecret 使用AES-GCM加密密钥（加密密钥由key经SHA-256派生），返回十六进制密文（含nonce）
+func EncryptSecret(key, plaintext string) (string, error) {
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// DecryptSecret 解密EncryptSecret生成的密文
+func DecryptSecret(key, ciphertext string) (string, error) {
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := hex.DecodeString(ciphertext)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", errors.New("密文格式错误")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("解密失败")
+	}
+	return string(plaintext), nil
+}
+
+// newSecretGCM 由配置的加密密钥派生AES-256-GCM
+func newSecretGCM(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("未配置加密密钥")
+	}
+	derived := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
 }
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NFTMetadata NFT元数据（OpenSea元数据标准）
+type NFTMetadata struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Image       string         `json:"image"`
+	Attributes  []NFTAttribute `json:"attributes"`
+}
+
+// NFTAttribute NFT特征
+type NFTAttribute struct {
+	TraitType string      `json:"trait_type"`
+	Value     interface{} `json:"value"`
+}
+
+// HasTrait 判断元数据是否包含指定特征（忽略大小写）
+func (m *NFTMetadata) HasTrait(traitType, value string) bool {
+	for _, attr := range m.Attributes {
+		if strings.EqualFold(attr.TraitType, traitType) && strings.EqualFold(fmt.Sprint(attr.Value), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipfsHTTPClient IPFS网关请求客户端
+var ipfsHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// FetchNFTMetadata 通过IPFS网关获取NFT元数据
+// CID内容不可变，结果缓存到Redis（24小时）
+// params: gateway-IPFS网关地址（如https://ipfs.io/ipfs/）, cid-元数据CID
+func FetchNFTMetadata(ctx context.Context, gateway, cid string) (*NFTMetadata, error) {
+	if cid == "" {
+		return nil, fmt.Errorf("metadata cid is empty")
+	}
+
+	// 1. 优先读取缓存
+	cacheKey := fmt.Sprintf("nft_metadata_%s", cid)
+	var body []byte
+	if RedisClient != nil {
+		if cached, err := RedisClient.Get(ctx, cacheKey).Bytes(); err == nil {
+			body = cached
+		}
+	}
+
+	// 2. 缓存未命中，请求IPFS网关
+	if body == nil {
+		url := strings.TrimSuffix(gateway, "/") + "/" + strings.TrimPrefix(cid, "ipfs://")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := ipfsHTTPClient.Do(req)
+		if err != nil {
+			Logger.Error("请求IPFS网关失败", zap.String("cid", cid), zap.Error(err))
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ipfs gateway status %d", resp.StatusCode)
+		}
+		body, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, err
+		}
+		if RedisClient != nil {
+			RedisClient.Set(ctx, cacheKey, body, 24*time.Hour)
+		}
+	}
+
+	// 3. 解析元数据
+	var metadata NFTMetadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil, fmt.Errorf("unmarshal metadata failed: %w", err)
+	}
+	return &metadata, nil
+}
@@ -53,6 +53,20 @@ func declareExchangeAndQueue() error {
 		return err
 	}
 
+	// 声明订单事件交换机（topic类型，下游按路由键订阅）
+	err = RabbitMQChannel.ExchangeDeclare(
+		"nft_event_exchange", // 交换机名
+		"topic",              // 类型
+		true,                 // 持久化
+		false,                // 自动删除
+		false,                // 内部
+		false,                // 等待
+		nil,                  // 参数
+	)
+	if err != nil {
+		return err
+	}
+
 	// 声明队列
 	_, err = RabbitMQChannel.QueueDeclare(
 		"nft_trade_queue", // 队列名
@@ -105,6 +119,35 @@ func PublishTradeMsg(ctx context.Context, orderNo string) error {
 	return err
 }
 
+// PublishOrderEvent 发布订单事件消息（如order.expired）
+// params: eventType-事件类型（同时作为路由键）, orderNo-订单编号, data-附加数据（可为nil）
+func PublishOrderEvent(ctx context.Context, eventType, orderNo string, data map[string]interface{}) error {
+	// 序列化消息
+	msg, err := json.Marshal(map[string]interface{}{
+		"event":      eventType,
+		"order_no":   orderNo,
+		"data":       data,
+		"event_time": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// 发布消息
+	return RabbitMQChannel.Publish(
+		"nft_event_exchange", // 交换机名
+		eventType,            // 路由键
+		false,                // 强制
+		false,                // 立即
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         msg,
+			DeliveryMode: amqp.Persistent, // 持久化
+			Timestamp:    time.Now(),
+		},
+	)
+}
+
 // ConsumeTradeMsg 消费交易执行消息
 func ConsumeTradeMsg(handler func(orderNo string) error) error {
 	msgs, err := RabbitMQChannel.Consume(
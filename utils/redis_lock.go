@@ -147,6 +147,22 @@ func GetRedisLock(ctx context.Context, key string, expire time.Duration) (*redsy
 	return mutex, nil
 }
 
+// TryRedisLock 尝试获取RedSync分布式锁（不重试，抢占失败立即返回）
+// 参数：ctx(上下文)、key(锁键)、expire(锁过期时间)
+// 返回：mutex(锁实例)、error(加锁失败原因，包括锁已被其他实例持有)
+func TryRedisLock(ctx context.Context, key string, expire time.Duration) (*redsync.Mutex, error) {
+	if Redisync == nil {
+		return nil, errors.New("redsync not initialized")
+	}
+
+	mutex := Redisync.NewMutex(key, redsync.WithExpiry(expire))
+	if err := mutex.TryLockContext(ctx); err != nil {
+		return nil, fmt.Errorf("redsync try lock failed: %w", err)
+	}
+
+	return mutex, nil
+}
+
 // ReleaseRedisLock 释放RedSync分布式锁
 // 参数：mutex(锁实例)
 // 返回：error(解锁失败原因，包括锁已过期)
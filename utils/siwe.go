@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// siweHeaderSuffix SIWE消息首行后缀
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// SIWEMessage Sign-In With Ethereum（EIP-4361）消息
+type SIWEMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSIWEMessage 解析EIP-4361消息文本
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, errors.New("SIWE消息格式错误")
+	}
+
+	msg := &SIWEMessage{
+		Domain:  strings.TrimSuffix(lines[0], siweHeaderSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if !common.IsHexAddress(msg.Address) {
+		return nil, errors.New("SIWE消息地址格式错误")
+	}
+
+	var statement []string
+	inResources := false
+	for _, line := range lines[2:] {
+		if inResources {
+			if resource, ok := strings.CutPrefix(line, "- "); ok {
+				msg.Resources = append(msg.Resources, resource)
+				continue
+			}
+			inResources = false
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			if line == "Resources:" {
+				inResources = true
+			} else if line != "" {
+				statement = append(statement, line)
+			}
+			continue
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.Atoi(value)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			statement = append(statement, line)
+		}
+		if err != nil {
+			return nil, errors.New("SIWE消息字段格式错误：" + key)
+		}
+	}
+	msg.Statement = strings.Join(statement, "\n")
+
+	if msg.URI == "" || msg.Nonce == "" || msg.ChainID == 0 || msg.IssuedAt.IsZero() {
+		return nil, errors.New("SIWE消息缺少必填字段")
+	}
+	if msg.Version != "1" {
+		return nil, errors.New("不支持的SIWE消息版本")
+	}
+	return msg, nil
+}
+
+// ValidAt 校验消息在指定时刻是否处于有效期内
+func (m *SIWEMessage) ValidAt(t time.Time) bool {
+	if m.ExpirationTime != nil && !t.Before(*m.ExpirationTime) {
+		return false
+	}
+	if m.NotBefore != nil && t.Before(*m.NotBefore) {
+		return false
+	}
+	return true
+}